@@ -0,0 +1,619 @@
+// Package archertest provides helpers for testing code built on archer
+// without a running PostgreSQL instance.
+package archertest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dyaksa/archer"
+	"github.com/dyaksa/archer/job"
+	"github.com/dyaksa/archer/store"
+)
+
+// Backend is an in-memory store.Backend. It follows the semantics of the
+// PostgreSQL backend: jobs are only claimed once their scheduled time has
+// passed, each job is claimed by a single poller, retries and timeouts bump
+// retry_count, and only scheduled jobs can be canceled.
+type Backend struct {
+	mu       sync.Mutex
+	jobs     map[string]*job.Job
+	archived map[string][]job.Job
+	paused   map[string]bool
+	limits   map[string]int
+	buckets  map[string]*store.RateLimit
+	deps     map[string][]string
+	now      func() time.Time
+}
+
+var (
+	_ store.Backend         = (*Backend)(nil)
+	_ store.Lister          = (*Backend)(nil)
+	_ store.Editor          = (*Backend)(nil)
+	_ store.BulkEditor      = (*Backend)(nil)
+	_ store.Purger          = (*Backend)(nil)
+	_ store.StatsReader     = (*Backend)(nil)
+	_ store.QueueController = (*Backend)(nil)
+)
+
+func NewBackend() *Backend {
+	return &Backend{
+		jobs:     map[string]*job.Job{},
+		archived: map[string][]job.Job{},
+		paused:   map[string]bool{},
+		limits:   map[string]int{},
+		buckets:  map[string]*store.RateLimit{},
+		deps:     map[string][]string{},
+		now:      time.Now,
+	}
+}
+
+// SetClock makes the backend compare scheduling and timeout times against
+// clock instead of the wall clock. Clients created with archer.WithClock
+// install their clock automatically.
+func (b *Backend) SetClock(clock archer.Clock) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.now = clock.Now
+}
+
+// Jobs returns a copy of every stored job ordered by creation time.
+func (b *Backend) Jobs() []job.Job {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	jobs := make([]job.Job, 0, len(b.jobs))
+	for _, j := range b.jobs {
+		jobs = append(jobs, clone(j))
+	}
+
+	sort.SliceStable(jobs, func(i, k int) bool {
+		if jobs[i].CreatedAt.Equal(jobs[k].CreatedAt) {
+			return jobs[i].ID < jobs[k].ID
+		}
+		return jobs[i].CreatedAt.Before(jobs[k].CreatedAt)
+	})
+
+	return jobs
+}
+
+func (b *Backend) Create(ctx context.Context, j job.Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.jobs[j.ID]; ok {
+		return fmt.Errorf("job %s already exists", j.ID)
+	}
+
+	for _, id := range j.DependsOn {
+		if _, ok := b.jobs[id]; !ok {
+			return fmt.Errorf("dependency %s: %w", id, job.ErrorJobNotFound)
+		}
+	}
+
+	now := b.now()
+	j.Result = nil
+	j.LastError = ""
+	j.RetryCount = 0
+	j.StartedAt.Valid = false
+	j.CreatedAt = now
+	j.UpdadatedAt = now
+
+	delete(b.deps, j.ID)
+	if len(j.DependsOn) > 0 {
+		b.deps[j.ID] = append([]string(nil), j.DependsOn...)
+		if status, lastError := b.dependencyStatus(j.ID); status != job.StatusScheduled {
+			j.Status, j.LastError = status, lastError
+		}
+	}
+
+	stored := clone(&j)
+	stored.DependsOn = nil
+	b.jobs[j.ID] = &stored
+	return nil
+}
+
+// dependencyStatus returns blocked while a parent of id is unfinished and
+// canceled once one failed or was canceled. Missing parents are ignored.
+func (b *Backend) dependencyStatus(id string) (string, string) {
+	status := job.StatusScheduled
+	for _, parentID := range b.deps[id] {
+		parent, ok := b.jobs[parentID]
+		if !ok {
+			continue
+		}
+
+		switch parent.Status {
+		case job.StatusFailed, job.StatusCanceled:
+			return job.StatusCanceled, "dependency " + parentID + " " + parent.Status
+		case job.StatusCompleted:
+		default:
+			status = job.StatusBlocked
+		}
+	}
+
+	return status, ""
+}
+
+// settle schedules or cancels blocked jobs whose parents finished, until
+// chains of dependents are settled too.
+func (b *Backend) settle() {
+	for changed := true; changed; {
+		changed = false
+		for id := range b.deps {
+			j, ok := b.jobs[id]
+			if !ok || j.Status != job.StatusBlocked {
+				continue
+			}
+
+			status, lastError := b.dependencyStatus(id)
+			if status == job.StatusBlocked {
+				continue
+			}
+
+			j.Status = status
+			if lastError != "" {
+				j.LastError = lastError
+			}
+			j.UpdadatedAt = b.now()
+			changed = true
+		}
+	}
+}
+
+func (b *Backend) Get(ctx context.Context, id string) (*job.Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	j, ok := b.jobs[id]
+	if !ok {
+		return &job.Job{}, job.ErrorJobNotFound
+	}
+
+	res := clone(j)
+	return &res, nil
+}
+
+func (b *Backend) Poll(ctx context.Context, queueName string) (*job.Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.paused[queueName] {
+		return &job.Job{}, job.ErrorJobNotFound
+	}
+
+	if limit := b.limits[queueName]; limit > 0 && b.running(queueName) >= limit {
+		return &job.Job{}, job.ErrorJobNotFound
+	}
+
+	now := b.now()
+
+	var next *job.Job
+	for _, j := range b.jobs {
+		if j.QueueName != queueName || j.Status != job.StatusScheduled || j.ScheduleAt.After(now) {
+			continue
+		}
+
+		if next == nil || pollsBefore(j, next) {
+			next = j
+		}
+	}
+
+	if next == nil {
+		return &job.Job{}, job.ErrorJobNotFound
+	}
+
+	if bucket := b.buckets[queueName]; bucket != nil && !bucket.Take(now) {
+		return &job.Job{}, job.ErrorJobNotFound
+	}
+
+	next.Status = job.StatusInitialized
+	next.StartedAt.NullTime = sql.NullTime{Time: now, Valid: true}
+	next.UpdadatedAt = now
+
+	res := clone(next)
+	return &res, nil
+}
+
+func (b *Backend) running(queueName string) int {
+	n := 0
+	for _, j := range b.jobs {
+		if j.QueueName == queueName && j.Status == job.StatusInitialized {
+			n++
+		}
+	}
+	return n
+}
+
+// pollsBefore orders due jobs by schedule time, then creation time and id.
+func pollsBefore(j *job.Job, other *job.Job) bool {
+	if !j.ScheduleAt.Equal(other.ScheduleAt) {
+		return j.ScheduleAt.Before(other.ScheduleAt)
+	}
+
+	if !j.CreatedAt.Equal(other.CreatedAt) {
+		return j.CreatedAt.Before(other.CreatedAt)
+	}
+
+	return j.ID < other.ID
+}
+
+func (b *Backend) Update(ctx context.Context, j job.Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stored, ok := b.jobs[j.ID]
+	if !ok {
+		return nil
+	}
+
+	stored.Status = j.Status
+	stored.Result = append([]byte(nil), j.Result...)
+	stored.LastError = j.LastError
+	stored.RetryCount = j.RetryCount
+	stored.ScheduleAt = j.ScheduleAt
+	stored.UpdadatedAt = b.now()
+
+	b.settle()
+	return nil
+}
+
+func (b *Backend) RequeueTimeout(ctx context.Context, queueName string, timeout time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	for _, j := range b.jobs {
+		if j.QueueName != queueName || j.Status != job.StatusInitialized {
+			continue
+		}
+
+		if !j.StartedAt.Valid || !j.StartedAt.Time.Before(timeout) {
+			continue
+		}
+
+		j.Status = job.StatusScheduled
+		j.StartedAt.Valid = false
+		j.RetryCount++
+		j.UpdadatedAt = now
+	}
+
+	return nil
+}
+
+func (b *Backend) Search(ctx context.Context, limit int, offset int, search string) ([]*job.Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	jobs := []*job.Job{}
+	for _, j := range b.jobs {
+		if search != "" && !strings.Contains(j.ID, search) {
+			continue
+		}
+
+		res := clone(j)
+		jobs = append(jobs, &res)
+	}
+
+	sort.SliceStable(jobs, func(i, k int) bool {
+		return jobs[i].ScheduleAt.After(jobs[k].ScheduleAt)
+	})
+
+	if offset >= len(jobs) {
+		return []*job.Job{}, nil
+	}
+
+	jobs = jobs[offset:]
+	if limit >= 0 && limit < len(jobs) {
+		jobs = jobs[:limit]
+	}
+
+	return jobs, nil
+}
+
+func (b *Backend) List(ctx context.Context, filter store.ListFilter) (store.ListResult, error) {
+	var cursorAt time.Time
+	var cursorID string
+	if filter.Cursor != "" {
+		var err error
+		if cursorAt, cursorID, err = store.DecodeCursor(filter.Cursor); err != nil {
+			return store.ListResult{}, err
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	res := store.ListResult{Jobs: []job.Job{}}
+	for _, j := range b.jobs {
+		if !filter.Match(*j) {
+			continue
+		}
+
+		res.Total++
+		if filter.Cursor == "" || newerFirst(cursorAt, cursorID, j.CreatedAt, j.ID) {
+			res.Jobs = append(res.Jobs, clone(j))
+		}
+	}
+
+	sort.Slice(res.Jobs, func(i, k int) bool {
+		return newerFirst(res.Jobs[i].CreatedAt, res.Jobs[i].ID, res.Jobs[k].CreatedAt, res.Jobs[k].ID)
+	})
+
+	limit := filter.PageSize()
+	if len(res.Jobs) > limit {
+		res.Jobs = res.Jobs[:limit]
+		res.Next = store.EncodeCursor(res.Jobs[limit-1])
+	}
+
+	return res, nil
+}
+
+// newerFirst orders jobs by creation time and id, both descending, the order
+// used by List.
+func newerFirst(createdAt time.Time, id string, otherAt time.Time, otherID string) bool {
+	if createdAt.Equal(otherAt) {
+		return id > otherID
+	}
+	return createdAt.After(otherAt)
+}
+
+func (b *Backend) Deschedule(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	j, ok := b.jobs[id]
+	if !ok || (j.Status != job.StatusScheduled && j.Status != job.StatusBlocked) {
+		return nil
+	}
+
+	j.Status = job.StatusCanceled
+	j.UpdadatedAt = b.now()
+
+	b.settle()
+	return nil
+}
+
+func (b *Backend) ScheduleNow(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	j, ok := b.jobs[id]
+	if !ok || j.Status == job.StatusBlocked {
+		return nil
+	}
+
+	now := b.now()
+	j.Status = job.StatusScheduled
+	j.ScheduleAt = now
+	j.UpdadatedAt = now
+	return nil
+}
+
+func (b *Backend) Reschedule(ctx context.Context, id string, at time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	j, ok := b.jobs[id]
+	if !ok || j.Status == job.StatusInitialized {
+		return nil
+	}
+
+	if j.Status != job.StatusBlocked {
+		j.Status = job.StatusScheduled
+	}
+	j.ScheduleAt = at
+	j.UpdadatedAt = b.now()
+	return nil
+}
+
+func (b *Backend) Delete(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(id)
+	return nil
+}
+
+// remove deletes job id together with its dependencies, both as dependent
+// and as parent.
+func (b *Backend) remove(id string) {
+	delete(b.jobs, id)
+	delete(b.deps, id)
+	for child, parents := range b.deps {
+		b.deps[child] = slices.DeleteFunc(parents, func(p string) bool { return p == id })
+	}
+}
+
+func (b *Backend) RetryWhere(ctx context.Context, filter store.ListFilter) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	n := 0
+	for _, j := range b.jobs {
+		if j.Status == job.StatusInitialized || j.Status == job.StatusBlocked || !filter.Match(*j) {
+			continue
+		}
+
+		j.Status = job.StatusScheduled
+		if status, _ := b.dependencyStatus(j.ID); status != job.StatusScheduled {
+			j.Status = job.StatusBlocked
+		}
+		j.RetryCount = 0
+		j.LastError = ""
+		j.StartedAt.Valid = false
+		j.ScheduleAt = now
+		j.UpdadatedAt = now
+		n++
+	}
+
+	// jobs retried together with their parents stay blocked; the others
+	// are canceled again
+	b.settle()
+	return n, nil
+}
+
+func (b *Backend) CancelWhere(ctx context.Context, filter store.ListFilter) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := 0
+	for _, j := range b.jobs {
+		if (j.Status != job.StatusScheduled && j.Status != job.StatusBlocked) || !filter.Match(*j) {
+			continue
+		}
+
+		j.Status = job.StatusCanceled
+		j.UpdadatedAt = b.now()
+		n++
+	}
+
+	b.settle()
+	return n, nil
+}
+
+func (b *Backend) Purge(ctx context.Context, filter store.ListFilter, limit int, archiveTable string) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	batch := []*job.Job{}
+	for _, j := range b.jobs {
+		if filter.Match(*j) {
+			batch = append(batch, j)
+		}
+	}
+
+	sort.Slice(batch, func(i, k int) bool {
+		if batch[i].UpdadatedAt.Equal(batch[k].UpdadatedAt) {
+			return batch[i].ID < batch[k].ID
+		}
+		return batch[i].UpdadatedAt.Before(batch[k].UpdadatedAt)
+	})
+
+	if len(batch) > limit {
+		batch = batch[:limit]
+	}
+
+	for _, j := range batch {
+		if archiveTable != "" {
+			b.archived[archiveTable] = append(b.archived[archiveTable], clone(j))
+		}
+		b.remove(j.ID)
+	}
+
+	return len(batch), nil
+}
+
+// Archived returns the jobs purged into archiveTable, in purge order.
+func (b *Backend) Archived(archiveTable string) []job.Job {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]job.Job(nil), b.archived[archiveTable]...)
+}
+
+func (b *Backend) SetQueuePaused(ctx context.Context, queueName string, paused bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.paused[queueName] = paused
+	return nil
+}
+
+func (b *Backend) SetQueueConcurrency(ctx context.Context, queueName string, n int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.limits[queueName] = n
+	return nil
+}
+
+func (b *Backend) SetQueueRateLimit(ctx context.Context, queueName string, n int, per time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n <= 0 {
+		delete(b.buckets, queueName)
+		return nil
+	}
+
+	if bucket := b.buckets[queueName]; bucket != nil {
+		bucket.Limit, bucket.Per = n, per
+		return nil
+	}
+
+	b.buckets[queueName] = &store.RateLimit{Limit: n, Per: per}
+	return nil
+}
+
+func (b *Backend) Stats(ctx context.Context) ([]store.QueueStats, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	byQueue := map[string]store.QueueStats{}
+	for _, j := range b.jobs {
+		s, ok := byQueue[j.QueueName]
+		if !ok {
+			s = store.QueueStats{Queue: j.QueueName, Counts: map[string]int{}}
+			byQueue[j.QueueName] = s
+		}
+		s.Counts[j.Status]++
+	}
+
+	state := func(queue string) store.QueueStats {
+		s, ok := byQueue[queue]
+		if !ok {
+			s = store.QueueStats{Queue: queue, Counts: map[string]int{}}
+		}
+		return s
+	}
+
+	for queue, paused := range b.paused {
+		if paused {
+			s := state(queue)
+			s.Paused = true
+			byQueue[queue] = s
+		}
+	}
+
+	for queue, bucket := range b.buckets {
+		s := state(queue)
+		s.RateLimit = bucket.Limit
+		s.RatePeriod = bucket.Per
+		byQueue[queue] = s
+	}
+
+	for queue, limit := range b.limits {
+		if limit > 0 {
+			s := state(queue)
+			s.MaxConcurrency = limit
+			byQueue[queue] = s
+		}
+	}
+
+	stats := make([]store.QueueStats, 0, len(byQueue))
+	for _, s := range byQueue {
+		stats = append(stats, s)
+	}
+
+	sort.Slice(stats, func(i, k int) bool {
+		return stats[i].Queue < stats[k].Queue
+	})
+
+	return stats, nil
+}
+
+func clone(j *job.Job) job.Job {
+	c := *j
+	c.Arguments = append([]byte(nil), j.Arguments...)
+	c.Result = append([]byte(nil), j.Result...)
+	return c
+}
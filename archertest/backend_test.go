@@ -0,0 +1,713 @@
+package archertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dyaksa/archer"
+	"github.com/dyaksa/archer/dag"
+	"github.com/dyaksa/archer/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func waitStatus(t *testing.T, b *Backend, id string, status string) job.Job {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		j, err := b.Get(context.Background(), id)
+		if err == nil && j.Status == status {
+			return *j
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	j, _ := b.Get(context.Background(), id)
+	t.Fatalf("job %s did not reach status %s, got %s", id, status, j.Status)
+	return job.Job{}
+}
+
+func TestClientEndToEnd(t *testing.T) {
+	b := NewBackend()
+	c := archer.NewClientWithBackend(b, archer.WithSleepInterval(5*time.Millisecond))
+
+	var calls atomic.Int32
+	c.Register("emails", func(ctx context.Context, j job.Job) (any, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("smtp down")
+		}
+
+		var args map[string]string
+		if err := j.ParseArguments(&args); err != nil {
+			return nil, err
+		}
+		return map[string]string{"sent": args["to"]}, nil
+	})
+
+	go func() { _ = c.Start() }()
+	defer c.Stop()
+
+	_, err := c.Schedule(context.Background(), "j1", "emails", map[string]string{"to": "jane"}, archer.WithMaxRetries(1))
+	assert.NoError(t, err)
+
+	j := waitStatus(t, b, "j1", job.StatusCompleted)
+	assert.Equal(t, 1, j.RetryCount)
+	assert.Equal(t, "smtp down", j.LastError)
+	assert.JSONEq(t, `{"sent":"jane"}`, string(j.Result))
+}
+
+func TestBackendScheduling(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend()
+
+	now := time.Now()
+	assert.NoError(t, b.Create(ctx, job.Job{ID: "later", QueueName: "q", Status: job.StatusScheduled, ScheduleAt: now.Add(time.Hour)}))
+	assert.NoError(t, b.Create(ctx, job.Job{ID: "second", QueueName: "q", Status: job.StatusScheduled, ScheduleAt: now.Add(-time.Second)}))
+	assert.NoError(t, b.Create(ctx, job.Job{ID: "first", QueueName: "q", Status: job.StatusScheduled, ScheduleAt: now.Add(-time.Minute)}))
+	assert.Error(t, b.Create(ctx, job.Job{ID: "first", QueueName: "q"}))
+
+	j, err := b.Poll(ctx, "q")
+	assert.NoError(t, err)
+	assert.Equal(t, "first", j.ID)
+	assert.Equal(t, job.StatusInitialized, j.Status)
+	assert.True(t, j.StartedAt.Valid)
+
+	j, err = b.Poll(ctx, "q")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", j.ID)
+
+	_, err = b.Poll(ctx, "q")
+	assert.ErrorIs(t, err, job.ErrorJobNotFound)
+
+	assert.NoError(t, b.ScheduleNow(ctx, "later"))
+	j, err = b.Poll(ctx, "q")
+	assert.NoError(t, err)
+	assert.Equal(t, "later", j.ID)
+}
+
+func TestBackendReapAndCancel(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend()
+
+	assert.NoError(t, b.Create(ctx, job.Job{ID: "j1", QueueName: "q", Status: job.StatusScheduled}))
+	assert.NoError(t, b.Create(ctx, job.Job{ID: "j2", QueueName: "q", Status: job.StatusScheduled, ScheduleAt: time.Now().Add(time.Hour)}))
+
+	_, err := b.Poll(ctx, "q")
+	assert.NoError(t, err)
+
+	assert.NoError(t, b.RequeueTimeout(ctx, "q", time.Now().Add(-time.Minute)))
+	j, _ := b.Get(ctx, "j1")
+	assert.Equal(t, job.StatusInitialized, j.Status)
+
+	assert.NoError(t, b.RequeueTimeout(ctx, "q", time.Now().Add(time.Minute)))
+	j, _ = b.Get(ctx, "j1")
+	assert.Equal(t, job.StatusScheduled, j.Status)
+	assert.Equal(t, 1, j.RetryCount)
+	assert.False(t, j.StartedAt.Valid)
+
+	assert.NoError(t, b.Deschedule(ctx, "j2"))
+	j, _ = b.Get(ctx, "j2")
+	assert.Equal(t, job.StatusCanceled, j.Status)
+
+	_, err = b.Poll(ctx, "q")
+	assert.NoError(t, err)
+	assert.NoError(t, b.Deschedule(ctx, "j1"))
+	j, _ = b.Get(ctx, "j1")
+	assert.Equal(t, job.StatusInitialized, j.Status)
+}
+
+func TestBackendList(t *testing.T) {
+	ctx := context.Background()
+	clock := NewClock(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	b := NewBackend()
+	b.SetClock(clock)
+
+	for _, id := range []string{"a1", "a2", "b1", "a3"} {
+		clock.Advance(time.Minute)
+		assert.NoError(t, b.Create(ctx, job.Job{ID: id, QueueName: "q", Status: job.StatusScheduled}))
+	}
+
+	res, err := b.List(ctx, archer.ListFilter{IDPrefix: "a", Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, res.Total)
+	assert.Equal(t, "a3", res.Jobs[0].ID)
+	assert.Equal(t, "a2", res.Jobs[1].ID)
+
+	res, err = b.List(ctx, archer.ListFilter{IDPrefix: "a", Limit: 2, Cursor: res.Next})
+	assert.NoError(t, err)
+	assert.Len(t, res.Jobs, 1)
+	assert.Equal(t, "a1", res.Jobs[0].ID)
+	assert.Empty(t, res.Next)
+}
+
+func TestClientBulk(t *testing.T) {
+	ctx := context.Background()
+	clock := NewClock(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	b := NewBackend()
+	c := archer.NewClientWithBackend(b, archer.WithClock(clock))
+	c.Register("q", func(ctx context.Context, j job.Job) (any, error) {
+		if j.ID == "ok" {
+			return nil, nil
+		}
+		return nil, errors.New("down")
+	})
+
+	for _, id := range []string{"ok", "bad1", "bad2"} {
+		_, err := c.Schedule(ctx, id, "q", nil)
+		assert.NoError(t, err)
+	}
+	_, err := c.Schedule(ctx, "later", "q", nil, archer.WithScheduleTime(clock.Now().Add(time.Hour)))
+	assert.NoError(t, err)
+
+	_, err = Drain(ctx, c, "q")
+	assert.NoError(t, err)
+
+	n, err := c.RetryWhere(ctx, archer.ListFilter{Queue: "q"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n, "only failed jobs by default")
+
+	j, _ := b.Get(ctx, "ok")
+	assert.Equal(t, job.StatusCompleted, j.Status)
+
+	j, _ = b.Get(ctx, "bad1")
+	assert.Equal(t, job.StatusScheduled, j.Status)
+	assert.Equal(t, "", j.LastError)
+
+	n, err = c.CancelWhere(ctx, archer.ListFilter{Queue: "q", ScheduledAfter: clock.Now().Add(time.Minute)})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	j, _ = b.Get(ctx, "later")
+	assert.Equal(t, job.StatusCanceled, j.Status)
+}
+
+func TestClientRetention(t *testing.T) {
+	ctx := context.Background()
+	clock := NewClock(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	b := NewBackend()
+	c := archer.NewClientWithBackend(b,
+		archer.WithClock(clock),
+		archer.WithJanitorInterval(5*time.Millisecond),
+		archer.WithJanitorBatchSize(1),
+		archer.WithArchiveTable("jobs_archive"),
+	)
+	c.Register("q", func(ctx context.Context, j job.Job) (any, error) {
+		if j.ID == "bad" {
+			return nil, errors.New("down")
+		}
+		return nil, nil
+	}, archer.WithRetention(archer.Retention{Completed: time.Hour}))
+
+	for _, id := range []string{"ok1", "ok2", "bad"} {
+		_, err := c.Schedule(ctx, id, "q", nil, archer.WithMaxRetries(0))
+		assert.NoError(t, err)
+	}
+
+	_, err := Drain(ctx, c, "q")
+	assert.NoError(t, err)
+	clock.Advance(2 * time.Hour)
+
+	go func() { _ = c.Start() }()
+	defer c.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) && len(b.Jobs()) > 1 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	jobs := b.Jobs()
+	assert.Len(t, jobs, 1, "failed jobs have no retention")
+	assert.Len(t, b.Archived("jobs_archive"), 2)
+}
+
+func TestClientPauseQueue(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend()
+	c := archer.NewClientWithBackend(b)
+	c.Register("q", func(ctx context.Context, j job.Job) (any, error) {
+		return nil, nil
+	})
+
+	_, err := c.Schedule(ctx, "j1", "q", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, c.PauseQueue(ctx, "q"))
+
+	n, err := Drain(ctx, c, "q")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	stats, err := c.Stats(ctx)
+	assert.NoError(t, err)
+	assert.True(t, stats[0].Paused)
+
+	assert.NoError(t, c.ResumeQueue(ctx, "q"))
+
+	n, err = Drain(ctx, c, "q")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestClientGlobalConcurrency(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend()
+
+	var running, peak atomic.Int32
+	worker := func(ctx context.Context, j job.Job) (any, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		return nil, nil
+	}
+
+	// two clients stand in for two worker processes sharing the database
+	for i := 0; i < 2; i++ {
+		c := archer.NewClientWithBackend(b, archer.WithSleepInterval(time.Millisecond))
+		c.Register("q", worker, archer.WithInstances(3), archer.WithGlobalConcurrency(2))
+		go func() { _ = c.Start() }()
+		defer c.Stop()
+	}
+
+	c := archer.NewClientWithBackend(b)
+	for i := 0; i < 8; i++ {
+		_, err := c.Schedule(ctx, fmt.Sprintf("j%d", i), "q", nil)
+		assert.NoError(t, err)
+	}
+
+	for i := 0; i < 8; i++ {
+		waitStatus(t, b, fmt.Sprintf("j%d", i), job.StatusCompleted)
+	}
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestClientRateLimit(t *testing.T) {
+	ctx := context.Background()
+	clock := NewClock(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	c := archer.NewClientWithBackend(NewBackend(), archer.WithClock(clock))
+	c.Register("q", func(ctx context.Context, j job.Job) (any, error) {
+		return nil, nil
+	})
+
+	assert.Error(t, c.SetRateLimit(ctx, "q", 2, 0))
+	assert.NoError(t, c.SetRateLimit(ctx, "q", 2, time.Minute))
+
+	for i := 0; i < 5; i++ {
+		_, err := c.Schedule(ctx, fmt.Sprintf("j%d", i), "q", nil)
+		assert.NoError(t, err)
+	}
+
+	n, err := Drain(ctx, c, "q")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	clock.Advance(time.Minute)
+	n, err = Drain(ctx, c, "q")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	res, err := c.List(ctx, archer.ListFilter{Statuses: []string{job.StatusScheduled}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Total, "jobs over the limit stay scheduled")
+}
+
+func TestClientClearsStaleLimits(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend()
+
+	admin := archer.NewClientWithBackend(b)
+	assert.NoError(t, admin.SetGlobalConcurrency(ctx, "q", 2))
+	assert.NoError(t, admin.SetRateLimit(ctx, "q", 5, time.Minute))
+	_, err := admin.Schedule(ctx, "j1", "q", nil)
+	assert.NoError(t, err)
+
+	c := archer.NewClientWithBackend(b, archer.WithSleepInterval(time.Millisecond))
+	c.Register("q", func(ctx context.Context, j job.Job) (any, error) {
+		return nil, nil
+	})
+	go func() { _ = c.Start() }()
+	defer c.Stop()
+
+	waitStatus(t, b, "j1", job.StatusCompleted)
+
+	stats, err := admin.Stats(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, stats, 1)
+	assert.Zero(t, stats[0].MaxConcurrency)
+	assert.Zero(t, stats[0].RateLimit)
+}
+
+func TestClientConcurrentRegister(t *testing.T) {
+	ctx := context.Background()
+	c := archer.NewClientWithBackend(NewBackend())
+	worker := func(ctx context.Context, j job.Job) (any, error) {
+		return nil, nil
+	}
+	c.Register("q", worker)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, c.SetConcurrency("q", i%4))
+			c.Register(fmt.Sprintf("q%d", i), worker)
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		_, err := c.ProcessNext(ctx, "q")
+		assert.NoError(t, err)
+	}
+	<-done
+}
+
+func TestClientSetConcurrency(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend()
+	c := archer.NewClientWithBackend(b, archer.WithSleepInterval(time.Millisecond))
+
+	release := make(chan struct{})
+	var running atomic.Int32
+	c.Register("q", func(ctx context.Context, j job.Job) (any, error) {
+		running.Add(1)
+		defer running.Add(-1)
+		<-release
+		return nil, nil
+	})
+
+	assert.Error(t, c.SetConcurrency("missing", 2))
+	assert.Error(t, c.SetConcurrency("q", -1))
+
+	for i := 0; i < 3; i++ {
+		_, err := c.Schedule(ctx, fmt.Sprintf("j%d", i), "q", nil)
+		assert.NoError(t, err)
+	}
+
+	go func() { _ = c.Start() }()
+	defer c.Stop()
+
+	waitRunning := func(n int32) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) && running.Load() != n {
+			time.Sleep(time.Millisecond)
+		}
+		assert.Equal(t, n, running.Load())
+	}
+
+	waitRunning(1)
+	assert.NoError(t, c.SetConcurrency("q", 3))
+	waitRunning(3)
+
+	assert.NoError(t, c.SetConcurrency("q", 1))
+	close(release)
+	for i := 0; i < 3; i++ {
+		waitStatus(t, b, fmt.Sprintf("j%d", i), job.StatusCompleted)
+	}
+}
+
+func TestClientDependencies(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend()
+	c := archer.NewClientWithBackend(b)
+
+	var order []string
+	c.Register("q", func(ctx context.Context, j job.Job) (any, error) {
+		order = append(order, j.ID)
+		if j.ID == "bad" {
+			return nil, errors.New("down")
+		}
+		return nil, nil
+	})
+
+	_, err := c.Schedule(ctx, "report", "q", nil, archer.WithDependsOn("extract", "load"))
+	assert.Error(t, err, "parents must exist")
+
+	for _, id := range []string{"extract", "load", "bad"} {
+		_, err := c.Schedule(ctx, id, "q", nil)
+		assert.NoError(t, err)
+	}
+	_, err = c.Schedule(ctx, "report", "q", nil, archer.WithDependsOn("extract", "load"))
+	assert.NoError(t, err)
+	_, err = c.Schedule(ctx, "notify", "q", nil, archer.WithDependsOn("report", "bad"))
+	assert.NoError(t, err)
+
+	j, _ := b.Get(ctx, "report")
+	assert.Equal(t, job.StatusBlocked, j.Status)
+	_, err = c.ScheduleNow(ctx, "report")
+	assert.NoError(t, err)
+	j, _ = b.Get(ctx, "report")
+	assert.Equal(t, job.StatusBlocked, j.Status, "blocked jobs cannot be run now")
+
+	n, err := Drain(ctx, c, "q")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, "report", order[len(order)-1])
+
+	j, _ = b.Get(ctx, "notify")
+	assert.Equal(t, job.StatusCanceled, j.Status)
+	assert.Equal(t, "dependency bad failed", j.LastError)
+
+	_, err = c.RetryWhere(ctx, archer.ListFilter{ID: "notify", Statuses: []string{job.StatusCanceled}})
+	assert.NoError(t, err)
+	j, _ = b.Get(ctx, "notify")
+	assert.Equal(t, job.StatusCanceled, j.Status, "its parent still failed")
+
+	_, err = c.RetryWhere(ctx, archer.ListFilter{Statuses: []string{job.StatusFailed, job.StatusCanceled}})
+	assert.NoError(t, err)
+	j, _ = b.Get(ctx, "notify")
+	assert.Equal(t, job.StatusBlocked, j.Status, "it waits for its retried parent")
+}
+
+func TestClientWorkflow(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	b := NewBackend()
+	b.SetClock(clock)
+
+	calls := map[string]int{}
+	workflow := func() *dag.DAG {
+		split := &dag.Node{ID: "split", Run: func(ctx context.Context, input any) (any, error) {
+			calls["split"]++
+			return input, nil
+		}}
+		double := &dag.Node{ID: "double", Run: func(ctx context.Context, input any) (any, error) {
+			calls["double"]++
+			if input.(float64) == 2 && calls["double"] <= 2 {
+				return nil, errors.New("crash")
+			}
+			return input.(float64) * 2, nil
+		}}
+		many := &dag.Node{ID: "many", Run: func(ctx context.Context, input any) (any, error) {
+			calls["many"]++
+			return len(input.([]any)), nil
+		}}
+
+		split.Edges = []dag.Edge{
+			{To: "double", Foreach: true},
+			{To: "many", Condition: func(input any) bool { return len(input.([]any)) > 5 }},
+		}
+
+		d := dag.New(split)
+		d.AddNode(double)
+		d.AddNode(many)
+		return d
+	}
+
+	c := archer.NewClientWithBackend(b, archer.WithClock(clock))
+	c.RegisterWorkflow("flows", workflow())
+
+	err := c.ScheduleWorkflow(ctx, "w1", "flows", []int{1, 2, 3}, archer.WithMaxRetries(1), archer.WithRetryInterval(time.Minute))
+	assert.NoError(t, err)
+
+	n, err := Drain(ctx, c, "flows")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	j, _ := b.Get(ctx, "w1/double[1]")
+	assert.Equal(t, job.StatusScheduled, j.Status)
+
+	// a new process picks up the workflow where it stopped
+	c = archer.NewClientWithBackend(b, archer.WithClock(clock))
+	c.RegisterWorkflow("flows", workflow())
+	clock.Advance(time.Minute)
+
+	n, err = Drain(ctx, c, "flows")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, map[string]int{"split": 1, "double": 4}, calls)
+
+	jobs, err := c.Workflow(ctx, "w1")
+	assert.NoError(t, err)
+
+	results := map[string]string{}
+	for _, j := range jobs {
+		assert.Equal(t, job.StatusCompleted, j.Status)
+		results[j.ID] = string(j.Result)
+	}
+	assert.Equal(t, map[string]string{
+		"w1/split":     "[1,2,3]",
+		"w1/double[0]": "2",
+		"w1/double[1]": "4",
+		"w1/double[2]": "6",
+	}, results)
+}
+
+func TestClientWorkflowSuccessorExists(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend()
+
+	second := &dag.Node{ID: "second", Run: func(ctx context.Context, input any) (any, error) {
+		return input, nil
+	}}
+	first := &dag.Node{ID: "first", Run: func(ctx context.Context, input any) (any, error) {
+		return input, nil
+	}, Edges: []dag.Edge{{To: "second"}}}
+	d := dag.New(first)
+	d.AddNode(second)
+
+	c := archer.NewClientWithBackend(b)
+	c.RegisterWorkflow("flows", d)
+	assert.NoError(t, c.ScheduleWorkflow(ctx, "w1", "flows", 1))
+
+	// left behind by an earlier run of first that crashed before completing
+	_, err := c.Schedule(ctx, "w1/second", "flows", map[string]any{"workflow": "w1", "node": "second", "input": 1})
+	assert.NoError(t, err)
+
+	n, err := Drain(ctx, c, "flows")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	j, _ := b.Get(ctx, "w1/first")
+	assert.Equal(t, job.StatusCompleted, j.Status)
+}
+
+func TestClientWorkflowSeveralParents(t *testing.T) {
+	run := func(ctx context.Context, input any) (any, error) {
+		return input, nil
+	}
+	d := dag.New(&dag.Node{ID: "a", Run: run, Edges: []dag.Edge{{To: "b"}, {To: "c"}}})
+	d.AddNode(&dag.Node{ID: "b", Run: run, Edges: []dag.Edge{{To: "c"}}})
+	d.AddNode(&dag.Node{ID: "c", Run: run})
+
+	c := archer.NewClientWithBackend(NewBackend())
+	c.RegisterWorkflow("flows", d)
+
+	err := c.ScheduleWorkflow(context.Background(), "w1", "flows", 1)
+	assert.ErrorContains(t, err, "node c has several parents")
+}
+
+func TestClientWorkflowIDs(t *testing.T) {
+	ctx := context.Background()
+	c := archer.NewClientWithBackend(NewBackend())
+	c.RegisterWorkflow("flows", dag.New(&dag.Node{ID: "only", Run: func(ctx context.Context, input any) (any, error) {
+		return input, nil
+	}}))
+
+	assert.ErrorContains(t, c.ScheduleWorkflow(ctx, "a/b", "flows", 1), "must not contain /")
+
+	// more jobs than fit on one page
+	for i := 0; i < 501; i++ {
+		_, err := c.Schedule(ctx, fmt.Sprintf("a/n%d", i), "flows", nil)
+		assert.NoError(t, err)
+	}
+
+	jobs, err := c.Workflow(ctx, "a")
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 501)
+}
+
+func TestClientRegisterDAGWithoutArguments(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend()
+
+	var got any = "unset"
+	c := archer.NewClientWithBackend(b)
+	c.RegisterDAG("dags", dag.New(&dag.Node{ID: "start", Run: func(ctx context.Context, input any) (any, error) {
+		got = input
+		return "done", nil
+	}}))
+
+	_, err := c.Schedule(ctx, "d1", "dags", nil)
+	assert.NoError(t, err)
+
+	ran, err := c.ProcessNext(ctx, "dags")
+	assert.NoError(t, err)
+	assert.True(t, ran)
+	assert.Nil(t, got)
+
+	j, _ := b.Get(ctx, "d1")
+	assert.Equal(t, job.StatusCompleted, j.Status)
+}
+
+func TestClientRegisterDAG(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	b := NewBackend()
+	b.SetClock(clock)
+
+	calls := 0
+	sum := &dag.Node{ID: "sum", Run: func(ctx context.Context, input any) (any, error) {
+		total := 0.0
+		for _, v := range input.(map[string]any)["values"].([]any) {
+			total += v.(float64)
+		}
+		return total, nil
+	}, Edges: []dag.Edge{{To: "save"}}}
+	save := &dag.Node{ID: "save", Run: func(ctx context.Context, input any) (any, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("db down")
+		}
+		return map[string]any{"total": input}, nil
+	}}
+
+	d := dag.New(sum)
+	d.AddNode(save)
+
+	c := archer.NewClientWithBackend(b, archer.WithClock(clock))
+	c.RegisterDAG("sums", d)
+
+	_, err := c.Schedule(ctx, "s1", "sums", map[string]any{"values": []int{1, 2, 3}}, archer.WithMaxRetries(1), archer.WithRetryInterval(time.Minute))
+	assert.NoError(t, err)
+
+	n, err := Drain(ctx, c, "sums")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	j, _ := b.Get(ctx, "s1")
+	assert.Equal(t, job.StatusScheduled, j.Status)
+	assert.Equal(t, "db down", j.LastError)
+
+	clock.Advance(time.Minute)
+	n, err = Drain(ctx, c, "sums")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	j, _ = b.Get(ctx, "s1")
+	assert.Equal(t, job.StatusCompleted, j.Status)
+	assert.JSONEq(t, `{"total": 6}`, string(j.Result))
+}
+
+func TestClientWithTxUnsupported(t *testing.T) {
+	c := archer.NewClientWithBackend(NewBackend())
+
+	err := c.WithTx(nil).Schedule(context.Background(), "j1", "q", nil)
+	assert.ErrorIs(t, err, errors.ErrUnsupported)
+}
+
+// coreBackend hides the optional methods of Backend.
+type coreBackend struct {
+	archer.Backend
+}
+
+func TestClientOptionalBackend(t *testing.T) {
+	c := archer.NewClientWithBackend(coreBackend{NewBackend()})
+	ctx := context.Background()
+
+	_, err := c.Schedule(ctx, "j1", "q", nil)
+	assert.NoError(t, err)
+
+	_, err = c.List(ctx, archer.ListFilter{})
+	assert.ErrorIs(t, err, errors.ErrUnsupported)
+	assert.ErrorIs(t, c.Delete(ctx, "j1"), errors.ErrUnsupported)
+	_, err = c.RetryWhere(ctx, archer.ListFilter{})
+	assert.ErrorIs(t, err, errors.ErrUnsupported)
+	_, err = c.Purge(ctx, archer.ListFilter{}, 10)
+	assert.ErrorIs(t, err, errors.ErrUnsupported)
+	_, err = c.Stats(ctx)
+	assert.ErrorIs(t, err, errors.ErrUnsupported)
+	assert.ErrorIs(t, c.PauseQueue(ctx, "q"), errors.ErrUnsupported)
+}
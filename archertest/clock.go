@@ -0,0 +1,38 @@
+package archertest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a manually controlled archer.Clock. Time only moves when Set or
+// Advance is called.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+}
+
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
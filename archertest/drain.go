@@ -0,0 +1,31 @@
+package archertest
+
+import (
+	"context"
+
+	"github.com/dyaksa/archer"
+)
+
+// Drain runs the worker registered for queue inline until no job of the queue
+// is due, and returns the number of jobs processed. Jobs scheduled in the
+// future, including retries, stay in the queue; advance the Clock shared by
+// the client and the backend and call Drain again to run them.
+func Drain(ctx context.Context, c *archer.Client, queue string) (int, error) {
+	processed := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return processed, err
+		}
+
+		ok, err := c.ProcessNext(ctx, queue)
+		if err != nil {
+			return processed, err
+		}
+
+		if !ok {
+			return processed, nil
+		}
+
+		processed++
+	}
+}
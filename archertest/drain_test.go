@@ -0,0 +1,69 @@
+package archertest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dyaksa/archer"
+	"github.com/dyaksa/archer/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrain(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+
+	b := NewBackend()
+	c := archer.NewClientWithBackend(b, archer.WithClock(clock))
+
+	calls := map[string]int{}
+	c.Register("emails", func(ctx context.Context, j job.Job) (any, error) {
+		calls[j.ID]++
+		if j.ID == "flaky" && calls[j.ID] == 1 {
+			return nil, errors.New("smtp down")
+		}
+		return nil, nil
+	})
+
+	_, err := c.Schedule(ctx, "flaky", "emails", nil, archer.WithMaxRetries(1), archer.WithRetryInterval(time.Minute))
+	assert.NoError(t, err)
+	_, err = c.Schedule(ctx, "later", "emails", nil, archer.WithScheduleTime(start.Add(time.Hour)))
+	assert.NoError(t, err)
+
+	n, err := Drain(ctx, c, "emails")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	j, _ := b.Get(ctx, "flaky")
+	assert.Equal(t, job.StatusScheduled, j.Status)
+	assert.Equal(t, 1, j.RetryCount)
+	assert.Equal(t, start.Add(time.Minute), j.ScheduleAt)
+
+	clock.Advance(30 * time.Second)
+	n, err = Drain(ctx, c, "emails")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	clock.Advance(30 * time.Second)
+	n, err = Drain(ctx, c, "emails")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	j, _ = b.Get(ctx, "flaky")
+	assert.Equal(t, job.StatusCompleted, j.Status)
+
+	j, _ = b.Get(ctx, "later")
+	assert.Equal(t, job.StatusScheduled, j.Status)
+
+	clock.Advance(time.Hour)
+	n, err = Drain(ctx, c, "emails")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, map[string]int{"flaky": 2, "later": 1}, calls)
+
+	_, err = Drain(ctx, c, "unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,41 @@
+package archer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/dyaksa/archer/store"
+)
+
+// Backend is the storage used by Client, Queue and Mutate. See store.Backend
+// for the contract an implementation has to fulfil, and store.Lister and its
+// siblings for the optional features.
+type Backend = store.Backend
+
+// ListFilter selects jobs for Client.List. See store.ListFilter.
+type ListFilter = store.ListFilter
+
+// ListResult is a page of jobs returned by Client.List.
+type ListResult = store.ListResult
+
+// QueueStats counts the jobs of a queue by status.
+type QueueStats = store.QueueStats
+
+// migrator is implemented by backends that can create their own schema.
+type migrator interface {
+	Migrate(ctx context.Context) error
+}
+
+// txBackend is implemented by backends that can join a transaction owned by
+// the caller. Client.WithTx requires it.
+type txBackend interface {
+	WithTx(tx *sql.Tx) store.Backend
+}
+
+// unsupported reports that the backend lacks one of the optional interfaces
+// of package store.
+func unsupported(what string) error {
+	return fmt.Errorf("backend does not support %s: %w", what, errors.ErrUnsupported)
+}
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
 	"sync"
 	"time"
 
@@ -27,7 +30,7 @@ type Options struct {
 }
 
 type Client struct {
-	wrapper   wrapperTx
+	backend   Backend
 	tx        func(*sql.Tx) Tx
 	tableName string
 
@@ -41,13 +44,18 @@ type Client struct {
 
 	sleepInterval  time.Duration
 	reaperInterval time.Duration
+	scaleInterval  time.Duration
+	clock          Clock
+
+	janitorInterval  time.Duration
+	janitorBatchSize int
+	archiveTable     string
 
 	queue      func(name string) *Queue
 	coRoutines []func() error
-}
 
-type wrapperTx interface {
-	WrapTx(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) (any, error)) (any, error)
+	mu      sync.Mutex
+	scalers map[string]*scaler
 }
 
 func NewClient(opt *Options, options ...ClientOptionFunc) *Client {
@@ -76,67 +84,311 @@ func NewClient(opt *Options, options ...ClientOptionFunc) *Client {
 	db.SetMaxIdleConns(opt.MaxIdleConns)
 	db.SetMaxOpenConns(opt.MaxOpenConns)
 
-	errChan := make(chan error)
-	ctx, cancel := context.WithCancel(context.Background())
+	c := newClient(options...)
+
+	return c.setBackend(store.NewPostgres(db, c.tableName))
+}
+
+// NewClientWithBackend creates a client that stores its jobs in b instead of
+// the default Postgres backend.
+func NewClientWithBackend(b Backend, options ...ClientOptionFunc) *Client {
+	return newClient(options...).setBackend(b)
+}
 
+func newClient(options ...ClientOptionFunc) *Client {
 	c := &Client{}
 	c.sleepInterval = time.Second * 2   // default sleepinterval
 	c.reaperInterval = time.Second * 10 // default reaper interval
+	c.scaleInterval = time.Second * 10  // default scale interval
 	c.errHandler = defaultErrorHandler  // default errhandler
 	c.tableName = "jobs"                // sleep tableName
+	c.clock = systemClock{}             // default clock
+	c.janitorInterval = time.Minute     // default janitor interval
+	c.janitorBatchSize = 1000           // default janitor batch size
 
 	for _, opt := range options {
 		c = opt(c)
 	}
 
+	return c
+}
+
+func (c *Client) setBackend(b Backend) *Client {
+	errChan := make(chan error)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if cb, ok := b.(clockBackend); ok {
+		cb.SetClock(c.clock)
+	}
+
 	c.register = newRegister()
-	c.wrapper = store.NewWrapperTx(db)
+	c.backend = b
 	c.spawn = newSpawner(ctx, errChan)
 	c.errChan = errChan
 	c.shutdown = cancel
 	c.tx = func(tx *sql.Tx) Tx {
-		return newTx(tx, c.tableName)
+		tb, ok := b.(txBackend)
+		if !ok {
+			return errTx{err: unsupported("external transactions")}
+		}
+		return newBackendTx(tb.WithTx(tx), c.clock)
 	}
 	c.queue = func(name string) *Queue {
-		return NewQueue(db, name, c.tableName)
+		q := NewBackendQueue(b, name)
+		q.now = c.clock.Now
+		return q
 	}
 
-	c.mutate = newMutate(db, c.tableName)
+	c.mutate = newMutate(b)
 
 	return c
 }
 
+// WithTx returns a Tx writing jobs through tx, so they commit or roll back
+// with the caller's own changes. When the backend cannot join a transaction
+// every call of the returned Tx fails with an error wrapping
+// errors.ErrUnsupported.
 func (c *Client) WithTx(tx *sql.Tx) Tx {
 	return c.tx(tx)
 }
 
 func (c *Client) Schedule(ctx context.Context, id string, queueName string, arguments interface{}, options ...FnOptions) (any, error) {
-	return c.wrapper.WrapTx(ctx, func(ctx context.Context, tx *sql.Tx) (any, error) {
-		return nil, c.tx(tx).Schedule(ctx, id, queueName, arguments, options...)
-	})
+	return nil, newBackendTx(c.backend, c.clock).Schedule(ctx, id, queueName, arguments, options...)
 }
 
 func (c *Client) Cancel(ctx context.Context, id string) (any, error) {
-	return c.wrapper.WrapTx(ctx, func(ctx context.Context, tx *sql.Tx) (any, error) {
-		return nil, c.tx(tx).Cancel(ctx, id)
-	})
+	return nil, newBackendTx(c.backend, c.clock).Cancel(ctx, id)
 }
 
 func (c *Client) ScheduleNow(ctx context.Context, id string) (any, error) {
-	return c.wrapper.WrapTx(ctx, func(ctx context.Context, tx *sql.Tx) (any, error) {
-		return nil, c.tx(tx).ScheduleNow(ctx, id)
-	})
+	return nil, newBackendTx(c.backend, c.clock).ScheduleNow(ctx, id)
 }
 
 func (c *Client) Get(ctx context.Context, id string) (any, error) {
-	res, err := c.wrapper.WrapTx(ctx, func(ctx context.Context, tx *sql.Tx) (any, error) {
-		return c.tx(tx).Get(ctx, id)
-	})
+	res, err := newBackendTx(c.backend, c.clock).Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	return res.(*job.Job), nil
+	return res, nil
+}
+
+// List returns a page of jobs matching filter, newest first, together with
+// the total number of matches. Pass ListResult.Next as filter.Cursor to fetch
+// the following page.
+func (c *Client) List(ctx context.Context, filter ListFilter) (ListResult, error) {
+	l, ok := c.backend.(store.Lister)
+	if !ok {
+		return ListResult{}, unsupported("listing jobs")
+	}
+
+	return l.List(ctx, filter)
+}
+
+// Reschedule moves a job that is not currently running to at.
+func (c *Client) Reschedule(ctx context.Context, id string, at time.Time) error {
+	e, ok := c.backend.(store.Editor)
+	if !ok {
+		return unsupported("rescheduling jobs")
+	}
+
+	return e.Reschedule(ctx, id, at)
+}
+
+// Delete removes a job regardless of its status. Jobs blocked on it are
+// released once their other dependencies completed.
+func (c *Client) Delete(ctx context.Context, id string) error {
+	e, ok := c.backend.(store.Editor)
+	if !ok {
+		return unsupported("deleting jobs")
+	}
+
+	return e.Delete(ctx, id)
+}
+
+// RetryWhere reschedules jobs matching filter to run now and resets their
+// retry count. Without statuses in the filter only failed jobs are retried;
+// running jobs are never touched. Jobs whose dependencies have not all
+// completed are blocked again, or canceled again while one of them failed.
+// It returns the number of retried jobs.
+func (c *Client) RetryWhere(ctx context.Context, filter ListFilter) (int, error) {
+	b, ok := c.backend.(store.BulkEditor)
+	if !ok {
+		return 0, unsupported("bulk retries")
+	}
+
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []string{job.StatusFailed}
+	}
+
+	return b.RetryWhere(ctx, filter)
+}
+
+// CancelWhere cancels the scheduled jobs matching filter and returns how many
+// were canceled.
+func (c *Client) CancelWhere(ctx context.Context, filter ListFilter) (int, error) {
+	b, ok := c.backend.(store.BulkEditor)
+	if !ok {
+		return 0, unsupported("bulk cancellation")
+	}
+
+	return b.CancelWhere(ctx, filter)
+}
+
+// Purge deletes up to limit jobs matching filter, least recently updated
+// first, copying them to the archive table first when one is configured. It
+// returns the number of deleted jobs.
+func (c *Client) Purge(ctx context.Context, filter ListFilter, limit int) (int, error) {
+	if limit <= 0 {
+		return 0, fmt.Errorf("purge limit must be positive, got %d", limit)
+	}
+
+	p, ok := c.backend.(store.Purger)
+	if !ok {
+		return 0, unsupported("purging jobs")
+	}
+
+	return p.Purge(ctx, filter, limit, c.archiveTable)
+}
+
+// PauseQueue stops every worker process from claiming jobs of a queue until
+// ResumeQueue is called. Running jobs are not interrupted and jobs can still
+// be scheduled.
+func (c *Client) PauseQueue(ctx context.Context, queueName string) error {
+	q, ok := c.backend.(store.QueueController)
+	if !ok {
+		return unsupported("pausing queues")
+	}
+
+	return q.SetQueuePaused(ctx, queueName, true)
+}
+
+// ResumeQueue lets workers claim jobs of a paused queue again.
+func (c *Client) ResumeQueue(ctx context.Context, queueName string) error {
+	q, ok := c.backend.(store.QueueController)
+	if !ok {
+		return unsupported("pausing queues")
+	}
+
+	return q.SetQueuePaused(ctx, queueName, false)
+}
+
+// SetGlobalConcurrency limits how many jobs of a queue run at once across
+// every worker process. Jobs over the limit stay scheduled. Zero removes the
+// limit.
+func (c *Client) SetGlobalConcurrency(ctx context.Context, queueName string, n int) error {
+	q, ok := c.backend.(store.QueueController)
+	if !ok {
+		return unsupported("global concurrency limits")
+	}
+
+	return q.SetQueueConcurrency(ctx, queueName, n)
+}
+
+// SetRateLimit limits a queue to n claimed jobs per period across every
+// worker process. Jobs over the limit stay scheduled. Zero n removes the
+// limit.
+func (c *Client) SetRateLimit(ctx context.Context, queueName string, n int, per time.Duration) error {
+	if n > 0 && per <= 0 {
+		return fmt.Errorf("rate limit of queue %s: period must be positive", queueName)
+	}
+
+	q, ok := c.backend.(store.QueueController)
+	if !ok {
+		return unsupported("rate limits")
+	}
+
+	return q.SetQueueRateLimit(ctx, queueName, n, per)
+}
+
+// SetConcurrency runs n worker instances for a queue in this process without
+// restarting, turning autoscaling of the queue off. Instances removed finish
+// their current job first. Before Start it replaces the registered number of
+// instances.
+func (c *Client) SetConcurrency(queueName string, n int) error {
+	if n < 0 {
+		return fmt.Errorf("concurrency of queue %s must not be negative", queueName)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if s, ok := c.scalers[queueName]; ok {
+		s.SetConcurrency(n)
+		return nil
+	}
+
+	config, ok := c.register[queueName]
+	if !ok {
+		return fmt.Errorf("no worker registered for queue %s", queueName)
+	}
+
+	config.instances = n
+	config.minInstances, config.maxInstances = 0, 0
+	c.register[queueName] = config
+	return nil
+}
+
+// Register registers w as the worker of queue name. It is safe to call while
+// SetConcurrency or ProcessNext run, but workers registered after Start are
+// only used by ProcessNext.
+func (c *Client) Register(name string, w WorkerFn, opts ...WorkerOptionFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.register.Register(name, w, opts...)
+}
+
+// worker returns a copy of the config registered for queue name.
+func (c *Client) worker(name string) (registerConfig, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	config, ok := c.register[name]
+	return config, ok
+}
+
+// Stats counts jobs per queue and status and reports the state of paused or
+// concurrency limited queues.
+func (c *Client) Stats(ctx context.Context) ([]QueueStats, error) {
+	st, ok := c.backend.(store.StatsReader)
+	if !ok {
+		return nil, unsupported("stats")
+	}
+
+	return st.Stats(ctx)
+}
+
+// Migrate creates the jobs table when the backend supports it.
+func (c *Client) Migrate(ctx context.Context) error {
+	m, ok := c.backend.(migrator)
+	if !ok {
+		return errors.New("backend does not support migrations")
+	}
+
+	return m.Migrate(ctx)
+}
+
+// ProcessNext claims a single due job of queueName and runs its registered
+// worker inline, the same way a worker pool would. It returns false when no
+// job is due.
+func (c *Client) ProcessNext(ctx context.Context, queueName string) (bool, error) {
+	config, ok := c.worker(queueName)
+	if !ok {
+		return false, fmt.Errorf("no worker registered for queue %s", queueName)
+	}
+
+	j, err := c.queue(queueName).Poll(ctx)
+	if errors.Is(err, job.ErrorJobNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	h := newHandler(config.w, c.mutate, c.clock.Now, config.callbackSuccess, config.callbackFailed)
+	return true, h.Handle(ctx, *j)
 }
 
 func (c *Client) Stop() {
@@ -164,16 +416,43 @@ func (c *Client) start() {
 
 	go errorRoutine(c.errChan, c.errHandler, errwg)
 
-	for name, config := range c.register.getWorkers() {
+	c.mu.Lock()
+	workers := maps.Clone(c.register.getWorkers())
+	c.mu.Unlock()
+
+	for name, config := range workers {
 		q := c.queue(name)
 
-		for i := 0; i < config.instances; i++ {
-			s := newPool(q, c.mutate, config.w, c.sleepInterval, config.callbackSuccess, config.callbackFailed)
-			c.spawn.Spawn(s)
+		// Limits are stored with the queue, so they are written even when
+		// unset to clear those left behind by an earlier configuration.
+		if _, ok := c.backend.(store.QueueController); ok {
+			if err := c.SetGlobalConcurrency(context.Background(), name, config.maxConcurrency); err != nil {
+				c.errChan <- err
+			}
+
+			if err := c.SetRateLimit(context.Background(), name, config.rateLimit, config.ratePer); err != nil {
+				c.errChan <- err
+			}
+		} else if config.maxConcurrency > 0 || config.rateLimit > 0 {
+			c.errChan <- fmt.Errorf("limits of queue %s: %w", name, unsupported("global concurrency and rate limits"))
 		}
 
+		s := c.newScaler(name, q)
+		c.spawn.Spawn(s)
+
 		r := newReaper(q, c.reaperInterval, config.timeout)
 		c.spawn.Spawn(r)
+
+		if config.retention.enabled() {
+			p, ok := c.backend.(store.Purger)
+			if !ok {
+				c.errChan <- fmt.Errorf("retention of queue %s: %w", name, unsupported("purging jobs"))
+				continue
+			}
+
+			j := newJanitor(p, name, config.retention, c.janitorInterval, c.janitorBatchSize, c.archiveTable, c.clock.Now)
+			c.spawn.Spawn(j)
+		}
 	}
 
 	c.spawn.Wait()
@@ -183,6 +462,37 @@ func (c *Client) start() {
 	errwg.Wait()
 }
 
+// newScaler reads the queue's config under c.mu, so a concurrent
+// SetConcurrency either changes the config or finds the scaler.
+func (c *Client) newScaler(name string, q *Queue) *scaler {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	config := c.register[name]
+
+	newPool := func() *pool {
+		return newPool(q, c.mutate, config.w, c.sleepInterval, config.callbackSuccess, config.callbackFailed)
+	}
+
+	backlog := func(ctx context.Context) (int, error) {
+		res, err := c.List(ctx, ListFilter{
+			Queue:           name,
+			Statuses:        []string{job.StatusScheduled},
+			ScheduledBefore: c.clock.Now(),
+			Limit:           1,
+		})
+		return res.Total, err
+	}
+
+	s := newScaler(newPool, config.instances, config.minInstances, config.maxInstances, c.scaleInterval, backlog)
+	if c.scalers == nil {
+		c.scalers = map[string]*scaler{}
+	}
+	c.scalers[name] = s
+
+	return s
+}
+
 func errorRoutine(errChan <-chan error, errorHandler func(error), wg *sync.WaitGroup) {
 	defer wg.Done()
 	for err := range errChan {
@@ -0,0 +1,23 @@
+package archer
+
+import (
+	"time"
+
+	"github.com/dyaksa/archer/store"
+)
+
+// Clock tells the current time. The client uses it for scheduling times,
+// retry intervals and reaping, and installs it on backends that implement
+// SetClock, so it can be replaced to control time in tests.
+type Clock = store.Clock
+
+// clockBackend is implemented by backends that take timestamps from a Clock.
+type clockBackend interface {
+	SetClock(clock Clock)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+
+	"github.com/dyaksa/archer"
+	"github.com/dyaksa/archer/job"
+)
+
+var finished = []string{job.StatusCompleted, job.StatusFailed, job.StatusCanceled}
+
+type app struct {
+	client *archer.Client
+	out    io.Writer
+	now    func() time.Time
+}
+
+func newApp(c *archer.Client, out io.Writer) *app {
+	return &app{client: c, out: out, now: time.Now}
+}
+
+func (a *app) run(ctx context.Context, command string, args []string) error {
+	commands := map[string]func(context.Context, []string) error{
+		"migrate":      a.migrate,
+		"enqueue":      a.enqueue,
+		"get":          a.get,
+		"list":         a.list,
+		"cancel":       a.cancel,
+		"retry":        a.retry,
+		"retry-failed": a.retryFailed,
+		"purge":        a.purge,
+		"pause":        a.pause,
+		"resume":       a.resume,
+		"stats":        a.stats,
+	}
+
+	cmd, ok := commands[command]
+	if !ok {
+		return fmt.Errorf("unknown command %q", command)
+	}
+
+	return cmd(ctx, args)
+}
+
+func newFlags(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+func (a *app) migrate(ctx context.Context, args []string) error {
+	if err := a.client.Migrate(ctx); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(a.out, "migrated")
+	return nil
+}
+
+func (a *app) enqueue(ctx context.Context, args []string) error {
+	fs := newFlags("enqueue")
+	queue := fs.String("queue", "", "queue name (required)")
+	id := fs.String("id", "", "job id, a random UUID by default")
+	maxRetries := fs.Int("max-retries", 0, "maximum number of retries")
+	retryInterval := fs.Duration("retry-interval", 0, "delay between retries")
+	at := fs.String("at", "", "schedule time in RFC 3339, now by default")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *queue == "" {
+		return fmt.Errorf("enqueue: -queue is required")
+	}
+
+	arguments := json.RawMessage(`{}`)
+	if fs.NArg() > 0 {
+		arguments = json.RawMessage(fs.Arg(0))
+		if !json.Valid(arguments) {
+			return fmt.Errorf("enqueue: arguments are not valid JSON")
+		}
+	}
+
+	if *id == "" {
+		*id = uuid.NewString()
+	}
+
+	options := []archer.FnOptions{
+		archer.WithMaxRetries(*maxRetries),
+		archer.WithRetryInterval(*retryInterval),
+	}
+
+	if *at != "" {
+		t, err := time.Parse(time.RFC3339, *at)
+		if err != nil {
+			return fmt.Errorf("enqueue: %w", err)
+		}
+		options = append(options, archer.WithScheduleTime(t))
+	}
+
+	if _, err := a.client.Schedule(ctx, *id, *queue, arguments, options...); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(a.out, *id)
+	return nil
+}
+
+func (a *app) get(ctx context.Context, args []string) error {
+	id, err := single("get", "job id", args)
+	if err != nil {
+		return err
+	}
+
+	j, err := a.client.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(a.out, string(b))
+	return nil
+}
+
+func (a *app) list(ctx context.Context, args []string) error {
+	fs := newFlags("list")
+	filter := archer.ListFilter{}
+	fs.StringVar(&filter.Queue, "queue", "", "queue name")
+	fs.StringVar(&filter.IDPrefix, "prefix", "", "id prefix")
+	fs.StringVar(&filter.Cursor, "cursor", "", "cursor printed by the previous page")
+	fs.IntVar(&filter.Limit, "limit", 50, "page size")
+	status := fs.String("status", "", "comma separated statuses")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *status != "" {
+		filter.Statuses = strings.Split(*status, ",")
+	}
+
+	res, err := a.client.List(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tQUEUE\tSTATUS\tRETRIES\tSCHEDULED\tERROR")
+	for _, j := range res.Jobs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d/%d\t%s\t%s\n", j.ID, j.QueueName, j.Status, j.RetryCount, j.MaxRetry, j.ScheduleAt.UTC().Format(time.RFC3339), j.LastError)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "total %d\n", res.Total)
+	if res.Next != "" {
+		fmt.Fprintf(a.out, "next -cursor %s\n", res.Next)
+	}
+
+	return nil
+}
+
+func (a *app) cancel(ctx context.Context, args []string) error {
+	id, err := single("cancel", "job id", args)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.client.Cancel(ctx, id)
+	return err
+}
+
+func (a *app) retry(ctx context.Context, args []string) error {
+	id, err := single("retry", "job id", args)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.client.ScheduleNow(ctx, id)
+	return err
+}
+
+func (a *app) retryFailed(ctx context.Context, args []string) error {
+	fs := newFlags("retry-failed")
+	queue := fs.String("queue", "", "queue name (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *queue == "" {
+		return fmt.Errorf("retry-failed: -queue is required")
+	}
+
+	n, err := a.client.RetryWhere(ctx, archer.ListFilter{Queue: *queue, Statuses: []string{job.StatusFailed}})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "retried %d jobs\n", n)
+	return nil
+}
+
+func (a *app) purge(ctx context.Context, args []string) error {
+	fs := newFlags("purge")
+	olderThan := fs.Duration("older-than", 0, "delete finished jobs created longer ago than this (required)")
+	queue := fs.String("queue", "", "queue name, all queues by default")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *olderThan <= 0 {
+		return fmt.Errorf("purge: -older-than is required")
+	}
+
+	filter := archer.ListFilter{
+		Queue:         *queue,
+		Statuses:      finished,
+		CreatedBefore: a.now().Add(-*olderThan),
+	}
+
+	n := 0
+	for {
+		purged, err := a.client.Purge(ctx, filter, 500)
+		n += purged
+		if err != nil {
+			return err
+		}
+
+		if purged < 500 {
+			break
+		}
+	}
+
+	fmt.Fprintf(a.out, "purged %d jobs\n", n)
+	return nil
+}
+
+func (a *app) pause(ctx context.Context, args []string) error {
+	queue, err := single("pause", "queue name", args)
+	if err != nil {
+		return err
+	}
+
+	return a.client.PauseQueue(ctx, queue)
+}
+
+func (a *app) resume(ctx context.Context, args []string) error {
+	queue, err := single("resume", "queue name", args)
+	if err != nil {
+		return err
+	}
+
+	return a.client.ResumeQueue(ctx, queue)
+}
+
+func (a *app) stats(ctx context.Context, args []string) error {
+	stats, err := a.client.Stats(ctx)
+	if err != nil {
+		return err
+	}
+
+	statuses := []string{job.StatusBlocked, job.StatusScheduled, job.StatusInitialized, job.StatusCompleted, job.StatusFailed, job.StatusCanceled}
+
+	w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "QUEUE\t"+strings.ToUpper(strings.Join(statuses, "\t"))+"\tTOTAL\tSTATE")
+	for _, s := range stats {
+		fmt.Fprint(w, s.Queue)
+		for _, status := range statuses {
+			fmt.Fprintf(w, "\t%d", s.Counts[status])
+		}
+
+		state := "active"
+		if s.Paused {
+			state = "paused"
+		}
+		fmt.Fprintf(w, "\t%d\t%s\n", s.Total(), state)
+	}
+
+	return w.Flush()
+}
+
+func single(command string, what string, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("%s: expected exactly one %s", command, what)
+	}
+	return args[0], nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dyaksa/archer"
+	"github.com/dyaksa/archer/archertest"
+	"github.com/dyaksa/archer/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp(t *testing.T) {
+	ctx := context.Background()
+	clock := archertest.NewClock(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	c := archer.NewClientWithBackend(archertest.NewBackend(), archer.WithClock(clock))
+	c.Register("emails", func(ctx context.Context, j job.Job) (any, error) {
+		return nil, errors.New("smtp down")
+	})
+
+	out := &bytes.Buffer{}
+	a := newApp(c, out)
+	a.now = clock.Now
+
+	exec := func(args ...string) string {
+		t.Helper()
+		out.Reset()
+		if err := a.run(ctx, args[0], args[1:]); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	assert.Equal(t, "j1\n", exec("enqueue", "-queue", "emails", "-id", "j1", `{"to":"jane"}`))
+	exec("enqueue", "-queue", "emails", "-id", "j2")
+	exec("enqueue", "-queue", "emails", "-id", "j3", "-at", "2030-01-01T00:00:00Z")
+	assert.Error(t, a.run(ctx, "enqueue", []string{"-queue", "emails", "{"}))
+	assert.Error(t, a.run(ctx, "enqueue", []string{`{}`}))
+
+	assert.Contains(t, exec("get", "j1"), `"arguments": {`)
+
+	n, err := archertest.Drain(ctx, c, "emails")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	list := exec("list", "-status", "failed")
+	assert.Contains(t, list, "smtp down")
+	assert.Contains(t, list, "total 2")
+
+	assert.Equal(t, "retried 2 jobs\n", exec("retry-failed", "-queue", "emails"))
+	res, _ := c.Get(ctx, "j1")
+	assert.Equal(t, job.StatusScheduled, res.(*job.Job).Status)
+
+	exec("cancel", "j3")
+	res, _ = c.Get(ctx, "j3")
+	assert.Equal(t, job.StatusCanceled, res.(*job.Job).Status)
+
+	stats := exec("stats")
+	assert.True(t, strings.HasPrefix(stats, "QUEUE"))
+	assert.Contains(t, stats, "emails")
+	assert.Contains(t, stats, "active")
+
+	exec("pause", "emails")
+	assert.Contains(t, exec("stats"), "paused")
+	exec("resume", "emails")
+	assert.Error(t, a.run(ctx, "pause", nil))
+
+	clock.Advance(48 * time.Hour)
+	assert.Equal(t, "purged 1 jobs\n", exec("purge", "-older-than", "24h"))
+	_, err = c.Get(ctx, "j3")
+	assert.ErrorIs(t, err, job.ErrorJobNotFound)
+
+	assert.Error(t, a.run(ctx, "migrate", nil))
+	assert.Error(t, a.run(ctx, "nope", nil))
+}
@@ -0,0 +1,81 @@
+// Command archerctl inspects and operates on archer job tables.
+//
+// Usage:
+//
+//	archerctl [global flags] <command> [flags] [args]
+//
+// Commands:
+//
+//	migrate                                 create the jobs table
+//	enqueue -queue q [flags] '<json args>'  schedule a job
+//	get <id>                                print a job as JSON
+//	list [-queue q] [-status s] [-prefix p] list jobs
+//	cancel <id>                             cancel a scheduled job
+//	retry <id>                              run a job again now
+//	retry-failed -queue q                   run every failed job of a queue again
+//	purge -older-than d [-queue q]          delete finished jobs created before now-d
+//	pause <queue>                           stop workers claiming jobs of a queue
+//	resume <queue>                          let workers claim jobs of a queue again
+//	stats                                   count jobs per queue and status
+//
+// Connection flags default to the ARCHER_ADDR, ARCHER_USER, ARCHER_PASSWORD,
+// ARCHER_DB, ARCHER_SSL, ARCHER_TABLE and ARCHER_ARCHIVE_TABLE environment
+// variables.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dyaksa/archer"
+)
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "archerctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("archerctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	opt := &archer.Options{}
+	fs.StringVar(&opt.Addr, "addr", env("ARCHER_ADDR", "localhost:5432"), "PostgreSQL host and port")
+	fs.StringVar(&opt.User, "user", env("ARCHER_USER", ""), "database user")
+	fs.StringVar(&opt.Password, "password", env("ARCHER_PASSWORD", ""), "database password")
+	fs.StringVar(&opt.DBName, "db", env("ARCHER_DB", ""), "database name")
+	fs.StringVar(&opt.SSL, "ssl", env("ARCHER_SSL", ""), "sslmode, disable by default")
+	table := fs.String("table", env("ARCHER_TABLE", "jobs"), "jobs table name, as set with archer.WithSetTableName")
+	archive := fs.String("archive-table", env("ARCHER_ARCHIVE_TABLE", ""), "copy purged jobs to this table, as set with archer.WithArchiveTable")
+
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: archerctl [global flags] <migrate|enqueue|get|list|cancel|retry|retry-failed|purge|pause|resume|stats> [flags] [args]")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("missing command")
+	}
+
+	opt.MaxOpenConns = 2
+	c := archer.NewClient(opt, archer.WithSetTableName(*table), archer.WithArchiveTable(*archive))
+
+	return newApp(c, stdout).run(ctx, fs.Arg(0), fs.Args()[1:])
+}
+
+func env(key string, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return fallback
+}
@@ -2,7 +2,12 @@ package dag
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 )
@@ -15,11 +20,24 @@ type Edge struct {
 	To        string
 	Condition ConditionFn
 	Foreach   bool
+	// Concurrency runs the items of a foreach edge in parallel, at most
+	// Concurrency at a time, and collects their outputs into a []any in
+	// input order. Zero runs the items one after another and keeps only the
+	// output of the last one.
+	Concurrency int
+	// CollectErrors makes a foreach edge run every item even when some fail
+	// and report all failures, instead of stopping at the first one.
+	CollectErrors bool
+	// Label names the condition in diagrams; specs set it to the name of
+	// the condition.
+	Label string
 }
 
 type DAG struct {
-	nodes map[string]*Node
-	start string
+	nodes      map[string]*Node
+	start      string
+	duplicates []string
+	observers  []Observer
 }
 
 type Node struct {
@@ -27,6 +45,37 @@ type Node struct {
 	Run    NodeFn
 	SubDag *DAG
 	Edges  []Edge
+	// Retry runs Run or SubDag again when it fails.
+	Retry RetryPolicy
+	// Timeout bounds every run of the node through its context.
+	Timeout time.Duration
+	// Fallback is the ID of a node run with the same input when the node
+	// still fails after its retries. Its output and edges take the place of
+	// those of the node.
+	Fallback string
+	// Join makes the node wait for all its upstream branches and run once
+	// with their outputs as Branches, keyed by the ID of the node each output
+	// came from. Branches whose conditions skipped the join are left out.
+	Join bool
+
+	// in and out are the types of typed nodes, see Step.
+	in  reflect.Type
+	out reflect.Type
+}
+
+// Branches is the input of a join node: the outputs of the nodes with an
+// edge to the join, keyed by node ID.
+type Branches map[string]any
+
+// IDs returns the IDs of the branches in sorted order.
+func (b Branches) IDs() []string {
+	ids := make([]string, 0, len(b))
+	for id := range b {
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+	return ids
 }
 
 func New(start *Node) *DAG {
@@ -40,94 +89,338 @@ func (d *DAG) AddNode(n *Node) {
 		d.nodes = make(map[string]*Node, 0)
 	}
 
+	if existing, ok := d.nodes[n.ID]; ok && existing != n {
+		d.duplicates = append(d.duplicates, n.ID)
+	}
+
 	d.nodes[n.ID] = n
 }
 
+// Execute validates the DAG and runs it from the start node with input.
 func (d *DAG) Execute(ctx context.Context, input any) (any, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
 	return d.executeFrom(ctx, d.start, input)
 }
 
-func (d *DAG) executeFrom(ctx context.Context, id string, input any) (any, error) {
+// Start returns the ID of the node execution begins with.
+func (d *DAG) Start() string {
+	return d.start
+}
+
+// Nodes returns the nodes of the DAG sorted by ID.
+func (d *DAG) Nodes() []*Node {
+	nodes := make([]*Node, 0, len(d.nodes))
+	for _, id := range d.ids() {
+		nodes = append(nodes, d.nodes[id])
+	}
+
+	return nodes
+}
+
+// Step runs the node id alone and returns its output together with the edges
+// to follow next: those without a condition or whose condition holds for the
+// output. It lets callers drive the graph one node at a time.
+func (d *DAG) Step(ctx context.Context, id string, input any) (any, []Edge, error) {
 	node, ok := d.nodes[id]
 	if !ok {
-		return nil, fmt.Errorf("node %s not found", id)
+		return nil, nil, fmt.Errorf("node %s not found", id)
 	}
 
-	var data = input
-	var err error
-
-	if node.SubDag != nil {
-		data, err = node.SubDag.Execute(ctx, data)
+	t := d.tracer(ctx)
+	if t == nil {
+		data, _, err := node.run(ctx, input)
 		if err != nil {
-			return nil, err
+			return d.fallback(ctx, node, input, err)
 		}
-	} else if node.Run != nil {
-		data, err = node.Run(ctx, data)
+
+		return data, node.follow(data, nil), nil
+	}
+
+	runCtx, trace, slot := t.start(ctx, node, input)
+	data, attempts, err := node.run(runCtx, input)
+	trace.Attempts = attempts
+	if err != nil {
+		trace.Err = err
+		t.finish(ctx, trace, slot)
+		return d.fallback(ctx, node, input, err)
+	}
+
+	trace.OutputSize = size(data)
+	edges := node.follow(data, trace)
+	t.finish(ctx, trace, slot)
+
+	return data, edges, nil
+}
+
+// fallback runs the fallback of node after it failed with err.
+func (d *DAG) fallback(ctx context.Context, node *Node, input any, err error) (any, []Edge, error) {
+	if node.Fallback == "" || ctx.Err() != nil {
+		return nil, nil, err
+	}
+
+	return d.Step(ctx, node.Fallback, input)
+}
+
+// follow returns the edges to follow after the node returned data, and
+// records the decisions in trace when set.
+func (n *Node) follow(data any, trace *NodeTrace) []Edge {
+	var edges []Edge
+	for _, e := range n.Edges {
+		taken := e.Condition == nil || e.Condition(data)
+		if trace != nil {
+			trace.Edges = append(trace.Edges, EdgeTrace{To: e.To, Taken: taken})
+		}
+
+		if taken {
+			edges = append(edges, e)
+		}
+	}
+
+	return edges
+}
+
+func (d *DAG) executeFrom(ctx context.Context, id string, input any) (any, error) {
+	r := &run{d: d, ctx: ctx, active: map[string]int{}, joins: map[string]Branches{}, below: map[string]map[string]bool{}}
+
+	r.mu.Lock()
+	r.schedule(id, input)
+	r.mu.Unlock()
+
+	if err := r.g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return r.last, nil
+}
+
+// run is one execution of a DAG. A node runs as soon as an edge reaches it,
+// except a join, which collects the outputs reaching it until no node that is
+// still running or waiting can reach it anymore. The output of the run is the
+// output of the last node without edges to follow.
+type run struct {
+	d   *DAG
+	ctx context.Context
+	g   errgroup.Group
+
+	mu     sync.Mutex
+	active map[string]int
+	joins  map[string]Branches
+	below  map[string]map[string]bool
+	failed bool
+	last   any
+}
+
+// schedule runs node id with input in its own goroutine. r.mu must be held.
+func (r *run) schedule(id string, input any) {
+	if r.failed {
+		return
+	}
+
+	r.active[id]++
+	r.g.Go(func() error {
+		out, edges, err := r.d.Step(r.ctx, id, input)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		defer r.done(id)
+
 		if err != nil {
-			return nil, err
+			r.failed = true
+			return err
 		}
+
+		if len(edges) == 0 {
+			r.last = out
+		}
+
+		for _, e := range edges {
+			if err := r.follow(id, e, out); err != nil {
+				r.failed = true
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// follow passes the output of node from along edge e. r.mu must be held.
+func (r *run) follow(from string, e Edge, out any) error {
+	if e.Foreach {
+		items, ok := Items(out)
+		if !ok {
+			return fmt.Errorf("node %s: foreach output is %T, not a slice", from, out)
+		}
+
+		r.active[e.To]++
+		r.g.Go(func() error {
+			out, err := r.d.foreach(r.ctx, e, items)
+
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			defer r.done(e.To)
+
+			if err != nil {
+				r.failed = true
+				return err
+			}
+
+			r.last = out
+			return nil
+		})
+
+		return nil
 	}
 
-	if len(node.Edges) == 0 {
-		return data, nil
+	if node := r.d.nodes[e.To]; node == nil || !node.Join {
+		r.schedule(e.To, out)
+		return nil
 	}
 
-	var edges []Edge
-	for _, e := range node.Edges {
-		if e.Condition != nil && !e.Condition(data) {
+	branches, ok := r.joins[e.To]
+	if !ok {
+		branches = Branches{}
+		r.joins[e.To] = branches
+	}
+
+	if _, ok := branches[from]; ok {
+		return fmt.Errorf("join %s reached from %s more than once", e.To, from)
+	}
+
+	branches[from] = out
+	return nil
+}
+
+// done marks one run of node id as finished and starts the joins nothing
+// can reach anymore. r.mu must be held.
+func (r *run) done(id string) {
+	r.active[id]--
+	if r.active[id] == 0 {
+		delete(r.active, id)
+	}
+
+	for join, branches := range r.joins {
+		if r.waiting(join) {
 			continue
 		}
 
-		edges = append(edges, e)
+		delete(r.joins, join)
+		r.schedule(join, branches)
 	}
+}
 
-	if len(edges) == 0 {
-		return data, nil
+// waiting reports whether a running node or another pending join can still
+// reach join.
+func (r *run) waiting(join string) bool {
+	for id := range r.active {
+		if r.reaches(id, join) {
+			return true
+		}
 	}
 
-	resCh := make(chan any, len(edges))
-	var g errgroup.Group
-	for _, edge := range edges {
-		e := edge
-		g.Go(func() error {
-			if e.Foreach {
-				items, ok := data.([]any)
-				if !ok {
-					return fmt.Errorf("data is not a slice")
-				}
+	for id := range r.joins {
+		if id != join && r.reaches(id, join) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *run) reaches(from string, to string) bool {
+	below, ok := r.below[from]
+	if !ok {
+		below = map[string]bool{}
+		for _, to := range r.d.next(from) {
+			r.d.reach(to, below)
+		}
+		r.below[from] = below
+	}
+
+	return below[to]
+}
+
+// Items returns the elements of a slice or array held by v, for foreach
+// edges. It reports false when v holds neither.
+func Items(v any) ([]any, bool) {
+	if items, ok := v.([]any); ok {
+		return items, true
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+
+	items := make([]any, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+
+	return items, true
+}
 
-				var out any
-				for _, item := range items {
-					var err error
-					out, err = d.executeFrom(ctx, e.To, item)
-					if err != nil {
-						return err
-					}
+// foreach runs the graph from e.To once for every item.
+func (d *DAG) foreach(ctx context.Context, e Edge, items []any) (any, error) {
+	if e.Concurrency <= 0 {
+		var last any
+		var errs []error
+		for i, item := range items {
+			out, err := d.executeFrom(ctx, e.To, item)
+			if err != nil {
+				if !e.CollectErrors {
+					return nil, fmt.Errorf("item %d: %w", i, err)
 				}
-				resCh <- out
-				return nil
+				errs = append(errs, fmt.Errorf("item %d: %w", i, err))
+				continue
 			}
+			last = out
+		}
+
+		return last, errors.Join(errs...)
+	}
 
-			out, err := d.executeFrom(ctx, e.To, data)
+	outs := make([]any, len(items))
+	errs := make([]error, len(items))
+
+	g, gctx := errgroup.WithContext(ctx)
+	if e.CollectErrors {
+		g = &errgroup.Group{}
+		gctx = ctx
+	}
+	g.SetLimit(e.Concurrency)
+
+	for i, item := range items {
+		if gctx.Err() != nil {
+			break
+		}
+
+		g.Go(func() error {
+			out, err := d.executeFrom(gctx, e.To, item)
 			if err != nil {
-				return err
+				errs[i] = fmt.Errorf("item %d: %w", i, err)
+				return errs[i]
 			}
-
-			resCh <- out
+			outs[i] = out
 			return nil
 		})
 	}
 
-	if err := g.Wait(); err != nil {
+	if err := g.Wait(); err != nil && !e.CollectErrors {
 		return nil, err
 	}
 
-	close(resCh)
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
 
-	var last any
-	for out := range resCh {
-		last = out
+	// items left unstarted when ctx was canceled
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	return last, nil
+	return outs, nil
 }
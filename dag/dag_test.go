@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -207,3 +209,148 @@ func TestDAGForeach(t *testing.T) {
 		assert.Equal(t, 2, count)
 	})
 }
+
+func TestDAGStep(t *testing.T) {
+	n1 := &Node{ID: "n1", Run: func(ctx context.Context, input any) (any, error) {
+		return input.(int) + 1, nil
+	}}
+	n1.Edges = []Edge{
+		{To: "small", Condition: func(input any) bool { return input.(int) < 10 }},
+		{To: "large", Condition: func(input any) bool { return input.(int) >= 10 }},
+		{To: "always"},
+	}
+
+	d := New(n1)
+	assert.Equal(t, "n1", d.Start())
+
+	out, edges, err := d.Step(context.Background(), "n1", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, out)
+	assert.Len(t, edges, 2)
+	assert.Equal(t, "small", edges[0].To)
+	assert.Equal(t, "always", edges[1].To)
+
+	_, _, err = d.Step(context.Background(), "missing", 1)
+	assert.Error(t, err)
+}
+
+func TestDAGJoin(t *testing.T) {
+	branch := func(id string, out any) *Node {
+		return &Node{ID: id, Run: func(ctx context.Context, input any) (any, error) {
+			return out, nil
+		}, Edges: []Edge{{To: "join"}}}
+	}
+
+	var got Branches
+	join := &Node{ID: "join", Join: true, Run: func(ctx context.Context, input any) (any, error) {
+		got = input.(Branches)
+		return len(got), nil
+	}}
+
+	t.Run("all branches", func(t *testing.T) {
+		start := &Node{ID: "start", Edges: []Edge{{To: "a"}, {To: "b"}, {To: "c"}}}
+		c := branch("c", "c")
+		c.Edges = []Edge{{To: "c2"}}
+
+		d := New(start)
+		d.AddNode(branch("a", "a"))
+		d.AddNode(branch("b", "b"))
+		d.AddNode(c)
+		d.AddNode(branch("c2", "c2"))
+		d.AddNode(join)
+
+		out, err := d.Execute(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, out)
+		assert.Equal(t, Branches{"a": "a", "b": "b", "c2": "c2"}, got)
+		assert.Equal(t, []string{"a", "b", "c2"}, got.IDs())
+	})
+
+	t.Run("skipped branch", func(t *testing.T) {
+		start := &Node{ID: "start", Edges: []Edge{
+			{To: "a"},
+			{To: "b", Condition: func(input any) bool { return false }},
+		}}
+
+		d := New(start)
+		d.AddNode(branch("a", "a"))
+		d.AddNode(branch("b", "b"))
+		d.AddNode(join)
+
+		out, err := d.Execute(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, out)
+		assert.Equal(t, Branches{"a": "a"}, got)
+	})
+
+	t.Run("reached twice", func(t *testing.T) {
+		start := &Node{ID: "start", Edges: []Edge{{To: "a"}, {To: "b"}}}
+		b := &Node{ID: "b", Edges: []Edge{{To: "a"}}}
+
+		d := New(start)
+		d.AddNode(branch("a", "a"))
+		d.AddNode(b)
+		d.AddNode(join)
+
+		_, err := d.Execute(context.Background(), nil)
+		assert.EqualError(t, err, "join join reached from a more than once")
+	})
+}
+
+type failKey struct{}
+
+func TestDAGParallelForeach(t *testing.T) {
+	items := &Node{ID: "items", Run: func(ctx context.Context, input any) (any, error) {
+		return []any{1, 2, 3, 4, 5, 6}, nil
+	}}
+
+	var running, peak atomic.Int32
+	square := &Node{ID: "square", Run: func(ctx context.Context, input any) (any, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		i := input.(int)
+		time.Sleep(time.Duration(6-i) * time.Millisecond)
+		if i%2 == 0 && ctx.Value(failKey{}) != nil {
+			return nil, fmt.Errorf("even %d", i)
+		}
+		return i * i, nil
+	}}
+
+	t.Run("collects outputs in order", func(t *testing.T) {
+		items.Edges = []Edge{{To: "square", Foreach: true, Concurrency: 2}}
+		d := New(items)
+		d.AddNode(square)
+
+		out, err := d.Execute(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []any{1, 4, 9, 16, 25, 36}, out)
+		assert.Equal(t, int32(2), peak.Load())
+	})
+
+	ctx := context.WithValue(context.Background(), failKey{}, true)
+
+	t.Run("fail fast", func(t *testing.T) {
+		items.Edges = []Edge{{To: "square", Foreach: true, Concurrency: 1}}
+		d := New(items)
+		d.AddNode(square)
+
+		_, err := d.Execute(ctx, nil)
+		assert.EqualError(t, err, "item 1: even 2")
+	})
+
+	t.Run("collect errors", func(t *testing.T) {
+		items.Edges = []Edge{{To: "square", Foreach: true, Concurrency: 3, CollectErrors: true}}
+		d := New(items)
+		d.AddNode(square)
+
+		_, err := d.Execute(ctx, nil)
+		assert.EqualError(t, err, "item 1: even 2\nitem 3: even 4\nitem 5: even 6")
+	})
+}
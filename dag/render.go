@@ -0,0 +1,204 @@
+package dag
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ToDOT renders the DAG in Graphviz DOT. The start node has a double border,
+// joins are diamonds and sub-DAGs are clusters. Conditional edges are dashed
+// and labeled with their Label, foreach edges are bold and fallbacks dotted.
+func (d *DAG) ToDOT() string {
+	var b strings.Builder
+	b.WriteString("digraph {\n\tcompound=true;\n")
+	d.writeDOT(&b, "", "\t")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (d *DAG) writeDOT(b *strings.Builder, prefix string, indent string) {
+	for _, n := range d.Nodes() {
+		name := prefix + n.ID
+		if n.SubDag != nil {
+			fmt.Fprintf(b, "%ssubgraph %s {\n%s\tlabel=%s;\n", indent, strconv.Quote("cluster_"+name), indent, strconv.Quote(n.ID))
+			n.SubDag.writeDOT(b, name+"/", indent+"\t")
+			fmt.Fprintf(b, "%s}\n", indent)
+			continue
+		}
+
+		var attrs []string
+		if prefix != "" {
+			attrs = append(attrs, "label="+strconv.Quote(n.ID))
+		}
+		if n.ID == d.start && prefix == "" {
+			attrs = append(attrs, "peripheries=2")
+		}
+		if n.Join {
+			attrs = append(attrs, "shape=diamond")
+		}
+		fmt.Fprintf(b, "%s%s%s;\n", indent, strconv.Quote(name), dotAttrs(attrs))
+	}
+
+	for _, n := range d.Nodes() {
+		for _, l := range d.links(n, prefix) {
+			var attrs []string
+			if l.label != "" {
+				attrs = append(attrs, "label="+strconv.Quote(l.label))
+			}
+			if l.style != "" {
+				attrs = append(attrs, "style="+l.style)
+			}
+			if l.fromCluster != "" {
+				attrs = append(attrs, "ltail="+strconv.Quote(l.fromCluster))
+			}
+			if l.toCluster != "" {
+				attrs = append(attrs, "lhead="+strconv.Quote(l.toCluster))
+			}
+			fmt.Fprintf(b, "%s%s -> %s%s;\n", indent, strconv.Quote(l.from), strconv.Quote(l.to), dotAttrs(attrs))
+		}
+	}
+}
+
+func dotAttrs(attrs []string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+
+	return " [" + strings.Join(attrs, ", ") + "]"
+}
+
+// ToMermaid renders the DAG as a Mermaid flowchart, with the same
+// conventions as ToDOT: sub-DAGs are subgraphs, joins hexagons, conditional
+// and fallback edges dotted and foreach edges thick.
+func (d *DAG) ToMermaid() string {
+	m := &mermaid{ids: map[string]string{}}
+	m.b.WriteString("flowchart TD\n")
+	m.write(d, "", "\t")
+	return m.b.String()
+}
+
+// mermaid assigns short IDs to node paths, as Mermaid IDs cannot hold every
+// character a node ID may contain.
+type mermaid struct {
+	b   strings.Builder
+	ids map[string]string
+}
+
+func (m *mermaid) id(name string) string {
+	id, ok := m.ids[name]
+	if !ok {
+		id = "n" + strconv.Itoa(len(m.ids))
+		m.ids[name] = id
+	}
+
+	return id
+}
+
+func (m *mermaid) write(d *DAG, prefix string, indent string) {
+	for _, n := range d.Nodes() {
+		name := prefix + n.ID
+		label := strings.ReplaceAll(n.ID, `"`, "#quot;")
+
+		switch {
+		case n.SubDag != nil:
+			fmt.Fprintf(&m.b, "%ssubgraph %s [\"%s\"]\n", indent, m.id(name), label)
+			m.write(n.SubDag, name+"/", indent+"\t")
+			fmt.Fprintf(&m.b, "%send\n", indent)
+		case n.Join:
+			fmt.Fprintf(&m.b, "%s%s{{\"%s\"}}\n", indent, m.id(name), label)
+		case n.ID == d.start && prefix == "":
+			fmt.Fprintf(&m.b, "%s%s([\"%s\"])\n", indent, m.id(name), label)
+		default:
+			fmt.Fprintf(&m.b, "%s%s[\"%s\"]\n", indent, m.id(name), label)
+		}
+	}
+
+	for _, n := range d.Nodes() {
+		for _, l := range d.links(n, prefix) {
+			// Mermaid links subgraphs directly.
+			from, to := l.from, l.to
+			if l.fromCluster != "" {
+				from = strings.TrimPrefix(l.fromCluster, "cluster_")
+			}
+			if l.toCluster != "" {
+				to = strings.TrimPrefix(l.toCluster, "cluster_")
+			}
+
+			arrow := "-->"
+			switch l.style {
+			case "dashed", "dotted":
+				arrow = "-.->"
+			case "bold":
+				arrow = "==>"
+			}
+
+			label := ""
+			if l.label != "" {
+				label = "|\"" + strings.ReplaceAll(l.label, `"`, "#quot;") + "\"|"
+			}
+			fmt.Fprintf(&m.b, "%s%s %s%s %s\n", indent, m.id(from), arrow, label, m.id(to))
+		}
+	}
+}
+
+// link is an edge or fallback as drawn, between node paths. An end at a
+// sub-DAG is drawn to its start node and clipped at its cluster.
+type link struct {
+	from        string
+	to          string
+	fromCluster string
+	toCluster   string
+	label       string
+	style       string
+}
+
+func (d *DAG) links(n *Node, prefix string) []link {
+	var links []link
+	add := func(to string, label string, style string) {
+		l := link{label: label, style: style}
+		l.from, l.fromCluster = d.endpoint(n.ID, prefix)
+		l.to, l.toCluster = d.endpoint(to, prefix)
+		links = append(links, l)
+	}
+
+	for _, e := range n.Edges {
+		var labels []string
+		style := ""
+		if e.Foreach {
+			style = "bold"
+			if e.Concurrency > 0 {
+				labels = append(labels, "foreach ("+strconv.Itoa(e.Concurrency)+")")
+			} else {
+				labels = append(labels, "foreach")
+			}
+		}
+		if e.Condition != nil {
+			style = "dashed"
+			if e.Label != "" {
+				labels = append([]string{e.Label}, labels...)
+			} else {
+				labels = append([]string{"if"}, labels...)
+			}
+		}
+		add(e.To, strings.Join(labels, ", "), style)
+	}
+
+	if n.Fallback != "" {
+		add(n.Fallback, "on error", "dotted")
+	}
+
+	return links
+}
+
+// endpoint returns the path of the node drawn for id, and the cluster to
+// clip at when id is a sub-DAG.
+func (d *DAG) endpoint(id string, prefix string) (string, string) {
+	n, ok := d.nodes[id]
+	if !ok || n.SubDag == nil {
+		return prefix + id, ""
+	}
+
+	inner, _ := n.SubDag.endpoint(n.SubDag.start, prefix+id+"/")
+	return inner, "cluster_" + prefix + id
+}
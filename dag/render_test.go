@@ -0,0 +1,67 @@
+package dag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func renderDAG() *DAG {
+	sub := New(&Node{ID: "parse", Edges: []Edge{{To: "store"}}})
+	sub.AddNode(&Node{ID: "store"})
+
+	start := &Node{ID: "fetch", Fallback: "cache", Edges: []Edge{
+		{To: "import", Condition: func(input any) bool { return true }, Label: "new"},
+		{To: "thumb", Foreach: true, Concurrency: 4},
+	}}
+
+	d := New(start)
+	d.AddNode(&Node{ID: "cache", Edges: []Edge{{To: "import"}}})
+	d.AddNode(&Node{ID: "import", SubDag: sub, Edges: []Edge{{To: "done"}}})
+	d.AddNode(&Node{ID: "thumb", Edges: []Edge{{To: "done"}}})
+	d.AddNode(&Node{ID: "done", Join: true})
+	return d
+}
+
+func TestToDOT(t *testing.T) {
+	assert.Equal(t, `digraph {
+	compound=true;
+	"cache";
+	"done" [shape=diamond];
+	"fetch" [peripheries=2];
+	subgraph "cluster_import" {
+		label="import";
+		"import/parse" [label="parse"];
+		"import/store" [label="store"];
+		"import/parse" -> "import/store";
+	}
+	"thumb";
+	"cache" -> "import/parse" [lhead="cluster_import"];
+	"fetch" -> "import/parse" [label="new", style=dashed, lhead="cluster_import"];
+	"fetch" -> "thumb" [label="foreach (4)", style=bold];
+	"fetch" -> "cache" [label="on error", style=dotted];
+	"import/parse" -> "done" [ltail="cluster_import"];
+	"thumb" -> "done";
+}
+`, renderDAG().ToDOT())
+}
+
+func TestToMermaid(t *testing.T) {
+	assert.Equal(t, `flowchart TD
+	n0["cache"]
+	n1{{"done"}}
+	n2(["fetch"])
+	subgraph n3 ["import"]
+		n4["parse"]
+		n5["store"]
+		n4 --> n5
+	end
+	n6["thumb"]
+	n0 --> n3
+	n2 -.->|"new"| n3
+	n2 ==>|"foreach (4)"| n6
+	n2 -.->|"on error"| n0
+	n3 --> n1
+	n6 --> n1
+`, renderDAG().ToMermaid())
+}
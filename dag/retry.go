@@ -0,0 +1,76 @@
+package dag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetryPolicy retries a failing node. Attempts is the total number of runs,
+// so values below 2 disable retries. Backoff is the wait before the second
+// run and doubles after every further failure, up to MaxBackoff when set.
+type RetryPolicy struct {
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// delay returns the wait after failed run number attempt, counted from 1.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+
+	return d
+}
+
+// run executes the node with its retry policy and timeout, and returns the
+// number of runs it took.
+func (n *Node) run(ctx context.Context, input any) (any, int, error) {
+	for attempt := 1; ; attempt++ {
+		out, err := n.attempt(ctx, input)
+		if err == nil || attempt >= n.Retry.Attempts || ctx.Err() != nil {
+			return out, attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, attempt, err
+		case <-time.After(n.Retry.delay(attempt)):
+		}
+	}
+}
+
+func (n *Node) attempt(ctx context.Context, input any) (any, error) {
+	if n.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.Timeout)
+		defer cancel()
+	}
+
+	var out any
+	var err error
+	switch {
+	case n.SubDag != nil:
+		out, err = n.SubDag.Execute(ctx, input)
+	case n.Run != nil:
+		out, err = n.Run(ctx, input)
+	default:
+		return input, nil
+	}
+
+	if err != nil && n.Timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("node %s timed out after %s: %w", n.ID, n.Timeout, err)
+	}
+
+	return out, err
+}
@@ -0,0 +1,81 @@
+package dag
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{Attempts: 5, Backoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	assert.Equal(t, time.Second, p.delay(1))
+	assert.Equal(t, 2*time.Second, p.delay(2))
+	assert.Equal(t, 4*time.Second, p.delay(3))
+	assert.Equal(t, 5*time.Second, p.delay(4))
+}
+
+func TestNodeRetry(t *testing.T) {
+	calls := 0
+	flaky := &Node{ID: "flaky", Retry: RetryPolicy{Attempts: 3, Backoff: time.Millisecond}, Run: func(ctx context.Context, input any) (any, error) {
+		calls++
+		if calls < 3 {
+			return nil, errors.New("unavailable")
+		}
+		return calls, nil
+	}}
+
+	out, err := New(flaky).Execute(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, out)
+
+	calls = -10
+	_, err = New(flaky).Execute(context.Background(), nil)
+	assert.EqualError(t, err, "unavailable")
+	assert.Equal(t, -7, calls)
+}
+
+func TestNodeTimeout(t *testing.T) {
+	slow := &Node{ID: "slow", Timeout: 10 * time.Millisecond, Run: func(ctx context.Context, input any) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+
+	_, err := New(slow).Execute(context.Background(), nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualError(t, err, "node slow timed out after 10ms: context deadline exceeded")
+}
+
+func TestNodeFallback(t *testing.T) {
+	var visited []string
+	visit := func(id string, err error) NodeFn {
+		return func(ctx context.Context, input any) (any, error) {
+			visited = append(visited, id)
+			return id, err
+		}
+	}
+
+	primary := &Node{ID: "primary", Fallback: "cache", Run: visit("primary", errors.New("down")), Edges: []Edge{{To: "render"}}}
+	cache := &Node{ID: "cache", Run: visit("cache", nil), Edges: []Edge{{To: "log"}}}
+
+	d := New(primary)
+	d.AddNode(cache)
+	d.AddNode(&Node{ID: "render", Run: visit("render", nil)})
+	d.AddNode(&Node{ID: "log", Run: func(ctx context.Context, input any) (any, error) {
+		visited = append(visited, "log")
+		return input, nil
+	}})
+
+	assert.NoError(t, d.Validate())
+
+	out, err := d.Execute(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "cache", out)
+	assert.Equal(t, []string{"primary", "cache", "log"}, visited)
+
+	d = New(&Node{ID: "primary", Fallback: "missing"})
+	assert.ErrorIs(t, d.Validate(), ErrDanglingEdge)
+}
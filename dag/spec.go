@@ -0,0 +1,199 @@
+package dag
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/goccy/go-json"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec describes a DAG declaratively, so it can be kept in JSON or YAML and
+// built with a Registry. Start defaults to the first node.
+type Spec struct {
+	Start string     `json:"start,omitempty" yaml:"start,omitempty"`
+	Nodes []NodeSpec `json:"nodes" yaml:"nodes"`
+}
+
+// NodeSpec describes a node. Run names a function registered with
+// RegisterNode; a node without Run or SubDag passes its input on. Durations
+// use time.ParseDuration syntax, e.g. "1.5s".
+type NodeSpec struct {
+	ID       string     `json:"id" yaml:"id"`
+	Run      string     `json:"run,omitempty" yaml:"run,omitempty"`
+	SubDag   *Spec      `json:"subdag,omitempty" yaml:"subdag,omitempty"`
+	Join     bool       `json:"join,omitempty" yaml:"join,omitempty"`
+	Retry    *RetrySpec `json:"retry,omitempty" yaml:"retry,omitempty"`
+	Timeout  string     `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Fallback string     `json:"fallback,omitempty" yaml:"fallback,omitempty"`
+	Edges    []EdgeSpec `json:"edges,omitempty" yaml:"edges,omitempty"`
+}
+
+// RetrySpec describes a RetryPolicy.
+type RetrySpec struct {
+	Attempts   int    `json:"attempts" yaml:"attempts"`
+	Backoff    string `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	MaxBackoff string `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty"`
+}
+
+// EdgeSpec describes an edge. Condition names a function registered with
+// RegisterCondition.
+type EdgeSpec struct {
+	To            string `json:"to" yaml:"to"`
+	Condition     string `json:"condition,omitempty" yaml:"condition,omitempty"`
+	Foreach       bool   `json:"foreach,omitempty" yaml:"foreach,omitempty"`
+	Concurrency   int    `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+	CollectErrors bool   `json:"collect_errors,omitempty" yaml:"collect_errors,omitempty"`
+}
+
+// Registry holds the node functions and conditions a Spec can refer to by
+// name. Register everything before loading specs.
+type Registry struct {
+	nodes      map[string]NodeFn
+	conditions map[string]ConditionFn
+}
+
+func NewRegistry() *Registry {
+	return &Registry{nodes: map[string]NodeFn{}, conditions: map[string]ConditionFn{}}
+}
+
+// RegisterNode makes fn available to specs as name.
+func (r *Registry) RegisterNode(name string, fn NodeFn) {
+	r.nodes[name] = fn
+}
+
+// RegisterCondition makes fn available to edge specs as name.
+func (r *Registry) RegisterCondition(name string, fn ConditionFn) {
+	r.conditions[name] = fn
+}
+
+// LoadJSON builds a DAG from a JSON spec. Unknown fields are rejected.
+func (r *Registry) LoadJSON(b []byte) (*DAG, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	var spec Spec
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("decode spec: %w", err)
+	}
+
+	return r.Load(spec)
+}
+
+// LoadYAML builds a DAG from a YAML spec. Unknown fields are rejected.
+func (r *Registry) LoadYAML(b []byte) (*DAG, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+
+	var spec Spec
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("decode spec: %w", err)
+	}
+
+	return r.Load(spec)
+}
+
+// Load builds a DAG from spec and validates it.
+func (r *Registry) Load(spec Spec) (*DAG, error) {
+	d, err := r.build(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (r *Registry) build(spec Spec) (*DAG, error) {
+	if len(spec.Nodes) == 0 {
+		return nil, fmt.Errorf("spec has no nodes")
+	}
+
+	d := &DAG{nodes: map[string]*Node{}, start: spec.Start}
+	if d.start == "" {
+		d.start = spec.Nodes[0].ID
+	}
+
+	for _, ns := range spec.Nodes {
+		n, err := r.node(ns)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", ns.ID, err)
+		}
+
+		d.AddNode(n)
+	}
+
+	if _, ok := d.nodes[d.start]; !ok {
+		return nil, fmt.Errorf("start node %s not found", d.start)
+	}
+
+	return d, nil
+}
+
+func (r *Registry) node(ns NodeSpec) (*Node, error) {
+	if ns.ID == "" {
+		return nil, fmt.Errorf("missing id")
+	}
+
+	n := &Node{ID: ns.ID, Join: ns.Join, Fallback: ns.Fallback}
+
+	switch {
+	case ns.Run != "" && ns.SubDag != nil:
+		return nil, fmt.Errorf("run and subdag are exclusive")
+	case ns.Run != "":
+		fn, ok := r.nodes[ns.Run]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %q", ns.Run)
+		}
+		n.Run = fn
+	case ns.SubDag != nil:
+		sub, err := r.build(*ns.SubDag)
+		if err != nil {
+			return nil, fmt.Errorf("subdag: %w", err)
+		}
+		n.SubDag = sub
+	}
+
+	var err error
+	if n.Timeout, err = duration(ns.Timeout); err != nil {
+		return nil, fmt.Errorf("timeout: %w", err)
+	}
+
+	if ns.Retry != nil {
+		n.Retry.Attempts = ns.Retry.Attempts
+		if n.Retry.Backoff, err = duration(ns.Retry.Backoff); err != nil {
+			return nil, fmt.Errorf("retry backoff: %w", err)
+		}
+		if n.Retry.MaxBackoff, err = duration(ns.Retry.MaxBackoff); err != nil {
+			return nil, fmt.Errorf("retry max_backoff: %w", err)
+		}
+	}
+
+	for _, es := range ns.Edges {
+		e := Edge{To: es.To, Foreach: es.Foreach, Concurrency: es.Concurrency, CollectErrors: es.CollectErrors}
+		if es.Condition != "" {
+			cond, ok := r.conditions[es.Condition]
+			if !ok {
+				return nil, fmt.Errorf("edge to %s: unknown condition %q", es.To, es.Condition)
+			}
+			e.Condition = cond
+			e.Label = es.Condition
+		}
+
+		n.Edges = append(n.Edges, e)
+	}
+
+	return n, nil
+}
+
+func duration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,96 @@
+package dag
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRegistry() *Registry {
+	r := NewRegistry()
+	r.RegisterNode("split", func(ctx context.Context, input any) (any, error) {
+		var items []any
+		for _, s := range strings.Split(input.(string), ",") {
+			items = append(items, s)
+		}
+		return items, nil
+	})
+	r.RegisterNode("upper", func(ctx context.Context, input any) (any, error) {
+		return strings.ToUpper(input.(string)), nil
+	})
+	r.RegisterCondition("many", func(input any) bool {
+		return len(input.([]any)) > 1
+	})
+	return r
+}
+
+func TestRegistryLoadYAML(t *testing.T) {
+	d, err := testRegistry().LoadYAML([]byte(`
+start: split
+nodes:
+  - id: split
+    run: split
+    edges:
+      - to: upper
+        foreach: true
+        concurrency: 2
+        condition: many
+  - id: upper
+    run: upper
+    timeout: 1s
+    retry:
+      attempts: 3
+      backoff: 100ms
+`))
+	assert.NoError(t, err)
+
+	upper := d.nodes["upper"]
+	assert.Equal(t, time.Second, upper.Timeout)
+	assert.Equal(t, RetryPolicy{Attempts: 3, Backoff: 100 * time.Millisecond}, upper.Retry)
+
+	out, err := d.Execute(context.Background(), "a,b,c")
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"A", "B", "C"}, out)
+
+	out, err = d.Execute(context.Background(), "a")
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"a"}, out)
+}
+
+func TestRegistryLoadJSON(t *testing.T) {
+	d, err := testRegistry().LoadJSON([]byte(`{
+		"nodes": [
+			{"id": "outer", "subdag": {"nodes": [{"id": "inner", "run": "upper"}]}}
+		]
+	}`))
+	assert.NoError(t, err)
+
+	out, err := d.Execute(context.Background(), "x")
+	assert.NoError(t, err)
+	assert.Equal(t, "X", out)
+}
+
+func TestRegistryLoadErrors(t *testing.T) {
+	r := testRegistry()
+
+	_, err := r.LoadJSON([]byte(`{"nodes": [{"id": "a", "run": "missing"}]}`))
+	assert.EqualError(t, err, `node a: unknown function "missing"`)
+
+	_, err = r.LoadJSON([]byte(`{"nodes": [{"id": "a", "edges": [{"to": "b", "condition": "nope"}]}]}`))
+	assert.EqualError(t, err, `node a: edge to b: unknown condition "nope"`)
+
+	_, err = r.LoadJSON([]byte(`{"nodes": [{"id": "a", "timeout": "soon"}]}`))
+	assert.ErrorContains(t, err, "node a: timeout:")
+
+	_, err = r.LoadYAML([]byte("nodes:\n  - id: a\n    colour: red\n"))
+	assert.ErrorContains(t, err, "field colour not found")
+
+	_, err = r.LoadYAML([]byte("nodes:\n  - id: a\n    edges:\n      - to: b\n"))
+	assert.ErrorIs(t, err, ErrDanglingEdge)
+
+	_, err = r.LoadYAML([]byte("start: z\nnodes:\n  - id: a\n"))
+	assert.EqualError(t, err, "start node z not found")
+}
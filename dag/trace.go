@@ -0,0 +1,139 @@
+package dag
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// Observer is notified when nodes start and finish. Nodes on different
+// branches run concurrently, so observers must be safe for concurrent use.
+type Observer interface {
+	NodeStarted(ctx context.Context, trace NodeTrace)
+	NodeFinished(ctx context.Context, trace NodeTrace)
+}
+
+// NodeTrace describes one run of a node. Sizes are the length of the value
+// encoded as JSON, or -1 when it cannot be encoded. Nodes of a sub-DAG are
+// reported with the ID of the sub-DAG node as prefix, e.g. "parent/child".
+type NodeTrace struct {
+	ID         string
+	Start      time.Time
+	End        time.Time
+	InputSize  int
+	OutputSize int
+	Attempts   int
+	Err        error
+	Edges      []EdgeTrace
+}
+
+// Duration returns how long the node ran, retries included.
+func (t NodeTrace) Duration() time.Duration {
+	return t.End.Sub(t.Start)
+}
+
+// EdgeTrace records whether an edge was followed after its node succeeded.
+type EdgeTrace struct {
+	To    string
+	Taken bool
+}
+
+// RunReport lists every node run of an execution in the order they started.
+type RunReport struct {
+	Start time.Time
+	End   time.Time
+	Nodes []NodeTrace
+}
+
+// AddObserver notifies o about the nodes run by Execute, ExecuteWithReport
+// and Step. Sub-DAGs report to the observers of the DAG they run in.
+func (d *DAG) AddObserver(o Observer) {
+	d.observers = append(d.observers, o)
+}
+
+// ExecuteWithReport runs the DAG like Execute and also returns a report of
+// the nodes that ran, including when execution failed.
+func (d *DAG) ExecuteWithReport(ctx context.Context, input any) (any, *RunReport, error) {
+	report := &RunReport{Start: time.Now()}
+	ctx = context.WithValue(ctx, tracerKey{}, &tracer{observers: d.observers, report: report, mu: &sync.Mutex{}})
+
+	out, err := d.Execute(ctx, input)
+	report.End = time.Now()
+
+	return out, report, err
+}
+
+type tracerKey struct{}
+
+// tracer collects the traces of one execution. It travels in the context so
+// that sub-DAGs and foreach items report to the same place.
+type tracer struct {
+	observers []Observer
+	report    *RunReport
+	mu        *sync.Mutex
+	prefix    string
+}
+
+// tracer returns the tracer of ctx, or a new one when d has observers.
+func (d *DAG) tracer(ctx context.Context) *tracer {
+	if t, ok := ctx.Value(tracerKey{}).(*tracer); ok {
+		return t
+	}
+
+	if len(d.observers) == 0 {
+		return nil
+	}
+
+	return &tracer{observers: d.observers, mu: &sync.Mutex{}}
+}
+
+// start records the start of node and reserves its slot in the report, so
+// nodes are reported in the order they started. The returned context reports
+// the nodes of its sub-DAG under its ID.
+func (t *tracer) start(ctx context.Context, node *Node, input any) (context.Context, *NodeTrace, int) {
+	trace := &NodeTrace{ID: t.prefix + node.ID, Start: time.Now(), InputSize: size(input), OutputSize: -1}
+
+	slot := -1
+	if t.report != nil {
+		t.mu.Lock()
+		slot = len(t.report.Nodes)
+		t.report.Nodes = append(t.report.Nodes, *trace)
+		t.mu.Unlock()
+	}
+
+	child := *t
+	child.prefix = trace.ID + "/"
+	ctx = context.WithValue(ctx, tracerKey{}, &child)
+
+	for _, o := range t.observers {
+		o.NodeStarted(ctx, *trace)
+	}
+
+	return ctx, trace, slot
+}
+
+// finish records the end of the node started with the given slot.
+func (t *tracer) finish(ctx context.Context, trace *NodeTrace, slot int) {
+	trace.End = time.Now()
+
+	if t.report != nil {
+		t.mu.Lock()
+		t.report.Nodes[slot] = *trace
+		t.mu.Unlock()
+	}
+
+	for _, o := range t.observers {
+		o.NodeFinished(ctx, *trace)
+	}
+}
+
+func size(v any) int {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return -1
+	}
+
+	return len(b)
+}
@@ -0,0 +1,89 @@
+package dag
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) NodeStarted(ctx context.Context, trace NodeTrace) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, "start "+trace.ID)
+}
+
+func (r *recorder) NodeFinished(ctx context.Context, trace NodeTrace) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, "end "+trace.ID)
+}
+
+func TestExecuteWithReport(t *testing.T) {
+	sub := New(&Node{ID: "inner", Run: func(ctx context.Context, input any) (any, error) {
+		return input, nil
+	}})
+
+	calls := 0
+	n1 := &Node{ID: "n1", Run: func(ctx context.Context, input any) (any, error) {
+		return "large", nil
+	}, Edges: []Edge{
+		{To: "small", Condition: func(input any) bool { return input == "small" }},
+		{To: "flaky", Condition: func(input any) bool { return input == "large" }},
+	}}
+	flaky := &Node{ID: "flaky", Retry: RetryPolicy{Attempts: 2}, Run: func(ctx context.Context, input any) (any, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("unavailable")
+		}
+		return []any{1, 2}, nil
+	}, Edges: []Edge{{To: "sub"}}}
+
+	d := New(n1)
+	d.AddNode(&Node{ID: "small"})
+	d.AddNode(flaky)
+	d.AddNode(&Node{ID: "sub", SubDag: sub})
+
+	rec := &recorder{}
+	d.AddObserver(rec)
+
+	out, report, err := d.ExecuteWithReport(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1, 2}, out)
+
+	assert.Len(t, report.Nodes, 4)
+	assert.Equal(t, "n1", report.Nodes[0].ID)
+	assert.Equal(t, 4, report.Nodes[0].InputSize)
+	assert.Equal(t, 7, report.Nodes[0].OutputSize)
+	assert.Equal(t, []EdgeTrace{{To: "small", Taken: false}, {To: "flaky", Taken: true}}, report.Nodes[0].Edges)
+
+	assert.Equal(t, "flaky", report.Nodes[1].ID)
+	assert.Equal(t, 2, report.Nodes[1].Attempts)
+	assert.Equal(t, 5, report.Nodes[1].OutputSize)
+
+	assert.Equal(t, "sub", report.Nodes[2].ID, "nodes are reported in start order")
+	assert.Equal(t, "sub/inner", report.Nodes[3].ID)
+	assert.False(t, report.Nodes[2].End.IsZero())
+	assert.False(t, report.End.Before(report.Start))
+
+	assert.Equal(t, []string{
+		"start n1", "end n1",
+		"start flaky", "end flaky",
+		"start sub", "start sub/inner", "end sub/inner", "end sub",
+	}, rec.events)
+
+	_, report, err = New(&Node{ID: "bad", Run: func(ctx context.Context, input any) (any, error) {
+		return nil, errors.New("boom")
+	}}).ExecuteWithReport(context.Background(), nil)
+	assert.Error(t, err)
+	assert.Len(t, report.Nodes, 1)
+	assert.EqualError(t, report.Nodes[0].Err, "boom")
+	assert.Equal(t, -1, report.Nodes[0].OutputSize)
+}
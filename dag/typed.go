@@ -0,0 +1,118 @@
+package dag
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/goccy/go-json"
+)
+
+// TypedNode is a node whose function has static input and output types.
+// Connect typed nodes with Then, ThenIf, ForEach and OrElse, so that the
+// compiler checks the types on both ends of every edge.
+type TypedNode[In, Out any] struct {
+	*Node
+}
+
+// Step returns a typed node running fn. The input is passed on as is when it
+// holds an In, and converted through JSON otherwise, e.g. for job arguments
+// or workflow outputs; inputs that cannot be converted fail the node with an
+// error naming both types.
+func Step[In, Out any](id string, fn func(ctx context.Context, in In) (Out, error)) TypedNode[In, Out] {
+	n := &Node{
+		ID: id,
+		Run: func(ctx context.Context, input any) (any, error) {
+			in, err := convert[In](input)
+			if err != nil {
+				return nil, fmt.Errorf("node %s: %w", id, err)
+			}
+
+			return fn(ctx, in)
+		},
+		in:  reflect.TypeFor[In](),
+		out: reflect.TypeFor[Out](),
+	}
+
+	return TypedNode[In, Out]{Node: n}
+}
+
+// Then adds an edge from from to to.
+func Then[In, Mid, Out any](from TypedNode[In, Mid], to TypedNode[Mid, Out]) {
+	from.Edges = append(from.Edges, Edge{To: to.ID})
+}
+
+// ThenIf adds an edge from from to to, followed when cond holds for the
+// output of from. A fallback with another output type never satisfies it.
+func ThenIf[In, Mid, Out any](from TypedNode[In, Mid], to TypedNode[Mid, Out], cond func(Mid) bool) {
+	from.Edges = append(from.Edges, Edge{To: to.ID, Condition: func(input any) bool {
+		v, ok := input.(Mid)
+		return ok && cond(v)
+	}})
+}
+
+// ForEach adds a foreach edge from from to to, running to once per item with
+// at most concurrency items at a time; zero runs them one after another.
+func ForEach[In, Item, Out any](from TypedNode[In, []Item], to TypedNode[Item, Out], concurrency int) {
+	from.Edges = append(from.Edges, Edge{To: to.ID, Foreach: true, Concurrency: concurrency})
+}
+
+// OrElse makes fallback run with the input of n when n fails.
+func OrElse[In, Out any](n TypedNode[In, Out], fallback TypedNode[In, Out]) {
+	n.Fallback = fallback.ID
+}
+
+func convert[T any](input any) (T, error) {
+	var v T
+	if input == nil {
+		return v, nil
+	}
+
+	if v, ok := input.(T); ok {
+		return v, nil
+	}
+
+	b, err := json.Marshal(input)
+	if err == nil {
+		err = json.Unmarshal(b, &v)
+	}
+	if err != nil {
+		return v, fmt.Errorf("cannot use input of type %T as %s: %w", input, reflect.TypeFor[T](), err)
+	}
+
+	return v, nil
+}
+
+// typeErrors checks the edges and fallback of node between typed nodes.
+func (d *DAG) typeErrors(n *Node) []error {
+	var errs []error
+	check := func(to string, out reflect.Type, in reflect.Type) {
+		if !out.AssignableTo(in) {
+			errs = append(errs, fmt.Errorf("%w: %s -> %s: %s is not %s", ErrTypeMismatch, n.ID, to, out, in))
+		}
+	}
+
+	for _, e := range n.Edges {
+		target, ok := d.nodes[e.To]
+		if !ok || n.out == nil || target.in == nil || target.Join {
+			continue
+		}
+
+		if !e.Foreach {
+			check(e.To, n.out, target.in)
+			continue
+		}
+
+		if k := n.out.Kind(); k != reflect.Slice && k != reflect.Array {
+			errs = append(errs, fmt.Errorf("%w: %s -> %s: foreach over %s, not a slice", ErrTypeMismatch, n.ID, e.To, n.out))
+			continue
+		}
+		check(e.To, n.out.Elem(), target.in)
+	}
+
+	if fb, ok := d.nodes[n.Fallback]; ok && n.in != nil && fb.in != nil {
+		check(n.Fallback, n.in, fb.in)
+	}
+
+	return errs
+}
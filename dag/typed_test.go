@@ -0,0 +1,101 @@
+package dag
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	ID    string   `json:"id"`
+	Items []string `json:"items"`
+}
+
+func TestTypedNodes(t *testing.T) {
+	items := Step("items", func(ctx context.Context, o order) ([]string, error) {
+		return o.Items, nil
+	})
+	upper := Step("upper", func(ctx context.Context, s string) (string, error) {
+		return strings.ToUpper(s), nil
+	})
+	count := Step("count", func(ctx context.Context, s []string) (int, error) {
+		return len(s), nil
+	})
+	ForEach(items, upper, 2)
+	ThenIf(items, count, func(s []string) bool { return len(s) > 1 })
+
+	d := New(items.Node)
+	d.AddNode(upper.Node)
+	d.AddNode(count.Node)
+	assert.NoError(t, d.Validate())
+
+	out, err := d.Execute(context.Background(), order{ID: "o1", Items: []string{"a"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"A"}, out)
+
+	// job arguments arrive decoded from JSON
+	out, err = d.Execute(context.Background(), map[string]any{"id": "o2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{}, out)
+
+	_, err = d.Execute(context.Background(), "o3")
+	assert.ErrorContains(t, err, "node items: cannot use input of type string as dag.order")
+}
+
+func TestTypedNodesFallback(t *testing.T) {
+	parse := Step("parse", func(ctx context.Context, s string) (int, error) {
+		return strconv.Atoi(s)
+	})
+	zero := Step("zero", func(ctx context.Context, s string) (int, error) {
+		return 0, nil
+	})
+	OrElse(parse, zero)
+
+	d := New(parse.Node)
+	d.AddNode(zero.Node)
+
+	out, err := d.Execute(context.Background(), "x")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, out)
+}
+
+func TestTypedNodesValidate(t *testing.T) {
+	items := Step("items", func(ctx context.Context, in string) ([]int, error) {
+		return nil, errors.New("unused")
+	})
+	upper := Step("upper", func(ctx context.Context, s string) (string, error) {
+		return s, nil
+	})
+	parse := Step("parse", func(ctx context.Context, n int) (string, error) {
+		return "", nil
+	})
+
+	// edges added by hand are checked when the DAG is validated
+	items.Edges = []Edge{{To: "upper"}, {To: "parse", Foreach: true}, {To: "upper", Foreach: true}}
+	upper.Edges = []Edge{{To: "parse", Foreach: true}}
+
+	d := New(items.Node)
+	d.AddNode(upper.Node)
+	d.AddNode(parse.Node)
+
+	err := d.Validate()
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.EqualError(t, err, strings.Join([]string{
+		"type mismatch: items -> upper: []int is not string",
+		"type mismatch: items -> upper: int is not string",
+		"type mismatch: upper -> parse: foreach over string, not a slice",
+	}, "\n"))
+}
+
+func TestItems(t *testing.T) {
+	items, ok := Items([]int{1, 2})
+	assert.True(t, ok)
+	assert.Equal(t, []any{1, 2}, items)
+
+	_, ok = Items("no")
+	assert.False(t, ok)
+}
@@ -0,0 +1,151 @@
+package dag
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrDuplicateNode = errors.New("duplicate node")
+	ErrDanglingEdge  = errors.New("edge to unknown node")
+	ErrCycle         = errors.New("cycle")
+	ErrUnreachable   = errors.New("unreachable node")
+	ErrTypeMismatch  = errors.New("type mismatch")
+)
+
+// Validate checks that node IDs are unique, every edge and fallback points
+// to a node of the DAG, the graph has no cycle and every node can be reached
+// from the start node. Edges between typed nodes must fit their types.
+// Sub-DAGs are validated too. All problems found are returned joined, each
+// wrapping one of the Err values of this package.
+func (d *DAG) Validate() error {
+	var errs []error
+
+	for _, id := range d.duplicates {
+		errs = append(errs, fmt.Errorf("%w: %s added more than once", ErrDuplicateNode, id))
+	}
+
+	ids := d.ids()
+	for _, id := range ids {
+		for _, to := range d.next(id) {
+			if _, ok := d.nodes[to]; !ok {
+				errs = append(errs, fmt.Errorf("%w: %s -> %s", ErrDanglingEdge, id, to))
+			}
+		}
+	}
+
+	for _, id := range ids {
+		errs = append(errs, d.typeErrors(d.nodes[id])...)
+	}
+
+	if cycle := d.cycle(ids); cycle != nil {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrCycle, strings.Join(cycle, " -> ")))
+	}
+
+	reached := map[string]bool{}
+	d.reach(d.start, reached)
+	for _, id := range ids {
+		if !reached[id] {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrUnreachable, id))
+		}
+	}
+
+	for _, id := range ids {
+		if sub := d.nodes[id].SubDag; sub != nil {
+			if err := sub.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("sub-dag of %s: %w", id, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ids returns the node IDs sorted, so validation reports problems in a
+// stable order.
+func (d *DAG) ids() []string {
+	ids := make([]string, 0, len(d.nodes))
+	for id := range d.nodes {
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+	return ids
+}
+
+// cycle returns the path of the first cycle found, starting and ending with
+// the same node, or nil.
+func (d *DAG) cycle(ids []string) []string {
+	const (
+		visiting = 1
+		done     = 2
+	)
+
+	state := map[string]int{}
+	var path []string
+
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		switch state[id] {
+		case visiting:
+			i := slices.Index(path, id)
+			return append(slices.Clone(path[i:]), id)
+		case done:
+			return nil
+		}
+
+		if _, ok := d.nodes[id]; !ok {
+			return nil
+		}
+
+		state[id] = visiting
+		path = append(path, id)
+		for _, to := range d.next(id) {
+			if cycle := visit(to); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+
+		return nil
+	}
+
+	for _, id := range ids {
+		if cycle := visit(id); cycle != nil {
+			return cycle
+		}
+	}
+
+	return nil
+}
+
+func (d *DAG) reach(id string, reached map[string]bool) {
+	if _, ok := d.nodes[id]; !ok || reached[id] {
+		return
+	}
+
+	reached[id] = true
+	for _, to := range d.next(id) {
+		d.reach(to, reached)
+	}
+}
+
+// next returns the IDs of the nodes that can run after node id: the targets
+// of its edges and its fallback.
+func (d *DAG) next(id string) []string {
+	node := d.nodes[id]
+
+	next := make([]string, 0, len(node.Edges)+1)
+	for _, e := range node.Edges {
+		next = append(next, e.To)
+	}
+
+	if node.Fallback != "" {
+		next = append(next, node.Fallback)
+	}
+
+	return next
+}
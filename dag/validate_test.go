@@ -0,0 +1,63 @@
+package dag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDAGValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		n1 := &Node{ID: "n1", Edges: []Edge{{To: "n2"}, {To: "n3"}}}
+		n2 := &Node{ID: "n2", Edges: []Edge{{To: "n3"}}}
+		n3 := &Node{ID: "n3"}
+
+		d := New(n1)
+		d.AddNode(n2)
+		d.AddNode(n3)
+		d.AddNode(n3)
+
+		assert.NoError(t, d.Validate())
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		n1 := &Node{ID: "n1", Edges: []Edge{{To: "n2"}}}
+		n2 := &Node{ID: "n2", Edges: []Edge{{To: "n3"}}}
+		n3 := &Node{ID: "n3", Edges: []Edge{{To: "n2"}}}
+
+		d := New(n1)
+		d.AddNode(n2)
+		d.AddNode(n3)
+
+		err := d.Validate()
+		assert.ErrorIs(t, err, ErrCycle)
+		assert.EqualError(t, err, "cycle: n2 -> n3 -> n2")
+
+		_, err = d.Execute(context.Background(), nil)
+		assert.ErrorIs(t, err, ErrCycle)
+	})
+
+	t.Run("dangling, unreachable and duplicate", func(t *testing.T) {
+		n1 := &Node{ID: "n1", Edges: []Edge{{To: "missing"}}}
+
+		d := New(n1)
+		d.AddNode(&Node{ID: "n2"})
+		d.AddNode(&Node{ID: "n2"})
+
+		err := d.Validate()
+		assert.ErrorIs(t, err, ErrDuplicateNode)
+		assert.ErrorIs(t, err, ErrDanglingEdge)
+		assert.ErrorIs(t, err, ErrUnreachable)
+		assert.EqualError(t, err, "duplicate node: n2 added more than once\nedge to unknown node: n1 -> missing\nunreachable node: n2")
+	})
+
+	t.Run("sub-dag", func(t *testing.T) {
+		sub := New(&Node{ID: "s1", Edges: []Edge{{To: "s2"}}})
+		d := New(&Node{ID: "n1", SubDag: sub})
+
+		err := d.Validate()
+		assert.ErrorIs(t, err, ErrDanglingEdge)
+		assert.EqualError(t, err, "sub-dag of n1: edge to unknown node: s1 -> s2")
+	})
+}
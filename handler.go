@@ -2,11 +2,9 @@ package archer
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	"github.com/dyaksa/archer/job"
-	"github.com/dyaksa/archer/store"
 )
 
 // mutate is an interface that defines a method for updating a job.
@@ -16,22 +14,14 @@ type mutate interface {
 }
 
 type Mutate struct {
-	store.WrapperTx
-	tx func(*sql.Tx) Tx
+	tx Tx
 }
 
-func newMutate(db *sql.DB, tableName string) *Mutate {
-	return &Mutate{
-		WrapperTx: *store.NewWrapperTx(db),
-		tx: func(tx *sql.Tx) Tx {
-			return newTx(tx, tableName)
-		},
-	}
+func newMutate(b Backend) *Mutate {
+	return &Mutate{tx: newBackendTx(b, systemClock{})}
 }
 
-// Update updates the given job in the database within a transaction.
-// It wraps the update operation in a transaction context and ensures
-// that the transaction is properly managed.
+// Update updates the given job through the configured backend.
 //
 // Parameters:
 //   - ctx: The context for the update operation.
@@ -40,23 +30,22 @@ func newMutate(db *sql.DB, tableName string) *Mutate {
 // Returns:
 //   - error: An error if the update operation fails, otherwise nil.
 func (m *Mutate) Update(ctx context.Context, j job.Job) error {
-	_, err := m.WrapTx(ctx, func(ctx context.Context, tx *sql.Tx) (any, error) {
-		return nil, m.tx(tx).Update(ctx, j)
-	})
-	return err
+	return m.tx.Update(ctx, j)
 }
 
 type handler struct {
 	worker          Worker
 	mutate          mutate
+	now             func() time.Time
 	callbackSuccess func(ctx context.Context, job job.Job, res any) (any, error)
 	callbackFailed  func(ctx context.Context, job job.Job, err error) (any, error)
 }
 
-func newHandler(w Worker, mutate mutate, callbackSuccess func(ctx context.Context, job job.Job, res any) (any, error), callbackFailed func(ctx context.Context, job job.Job, err error) (any, error)) *handler {
+func newHandler(w Worker, mutate mutate, now func() time.Time, callbackSuccess func(ctx context.Context, job job.Job, res any) (any, error), callbackFailed func(ctx context.Context, job job.Job, err error) (any, error)) *handler {
 	return &handler{
 		worker:          w,
 		mutate:          mutate,
+		now:             now,
 		callbackSuccess: callbackSuccess,
 		callbackFailed:  callbackFailed,
 	}
@@ -101,8 +90,7 @@ func (h *handler) failure(ctx context.Context, j job.Job, err error) error {
 	j = j.SetLastError(err)
 
 	if j.ShouldRetry() {
-		retryAt := time.Now().Add(j.RetryInterval)
-		j = j.ScheduleRetry(retryAt)
+		j = j.ScheduleRetry(h.now())
 		return h.mutate.Update(ctx, j)
 	}
 
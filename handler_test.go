@@ -0,0 +1,33 @@
+package archer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dyaksa/archer/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandlerRetrySchedule(t *testing.T) {
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	mockTx := new(MockTx)
+	mockTx.On("Update", mock.Anything, mock.Anything).Return(nil).Once()
+
+	w := &fnWorker{fn: func(ctx context.Context, j job.Job) (any, error) {
+		return nil, errors.New("down")
+	}}
+	h := newHandler(w, mockTx, func() time.Time { return now }, nil, nil)
+
+	j := job.Job{ID: "j1", Status: job.StatusInitialized, MaxRetry: 3, RetryInterval: time.Minute}
+	assert.NoError(t, h.Handle(context.Background(), j))
+
+	updated := mockTx.Calls[0].Arguments.Get(1).(job.Job)
+	assert.Equal(t, 1, updated.RetryCount)
+	assert.Equal(t, "down", updated.LastError)
+	// one interval after the failure, not two
+	assert.Equal(t, now.Add(time.Minute), updated.ScheduleAt)
+}
@@ -0,0 +1,95 @@
+package archer
+
+import (
+	"context"
+	"time"
+
+	"github.com/dyaksa/archer/job"
+	"github.com/dyaksa/archer/store"
+)
+
+// Retention is how long finished jobs of a queue are kept, counted from their
+// last update. A zero duration keeps jobs of that status forever.
+type Retention struct {
+	Completed time.Duration
+	Failed    time.Duration
+	Canceled  time.Duration
+}
+
+func (r Retention) enabled() bool {
+	return r.Completed > 0 || r.Failed > 0 || r.Canceled > 0
+}
+
+func newJanitor(backend store.Purger, queueName string, retention Retention, every time.Duration, batchSize int, archiveTable string, now func() time.Time) *janitor {
+	return &janitor{
+		backend:      backend,
+		queueName:    queueName,
+		retention:    retention,
+		ticker:       time.NewTicker(every),
+		batchSize:    batchSize,
+		archiveTable: archiveTable,
+		now:          now,
+	}
+}
+
+// janitor deletes finished jobs of a queue once they outlive the retention
+// policy, in batches of batchSize so a backlog never runs as one huge
+// statement.
+type janitor struct {
+	backend      store.Purger
+	queueName    string
+	retention    Retention
+	ticker       *time.Ticker
+	batchSize    int
+	archiveTable string
+	now          func() time.Time
+}
+
+func (j *janitor) Run(ctx context.Context, errChan chan<- error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-j.ticker.C:
+			if err := j.clean(ctx); err != nil {
+				errChan <- err
+			}
+		}
+	}
+}
+
+func (j *janitor) clean(ctx context.Context) error {
+	policies := []struct {
+		status string
+		keep   time.Duration
+	}{
+		{job.StatusCompleted, j.retention.Completed},
+		{job.StatusFailed, j.retention.Failed},
+		{job.StatusCanceled, j.retention.Canceled},
+	}
+
+	for _, p := range policies {
+		if p.keep <= 0 {
+			continue
+		}
+
+		filter := ListFilter{
+			Queue:         j.queueName,
+			Statuses:      []string{p.status},
+			UpdatedBefore: j.now().Add(-p.keep),
+		}
+
+		for ctx.Err() == nil {
+			n, err := j.backend.Purge(ctx, filter, j.batchSize, j.archiveTable)
+			if err != nil {
+				return err
+			}
+
+			if n < j.batchSize {
+				break
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,17 @@
+package archer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJanitorBatchSize(t *testing.T) {
+	assert.Equal(t, 1000, newClient(WithJanitorBatchSize(0)).janitorBatchSize)
+	assert.Equal(t, 1000, newClient(WithJanitorBatchSize(-5)).janitorBatchSize)
+	assert.Equal(t, 20, newClient(WithJanitorBatchSize(20)).janitorBatchSize)
+
+	_, err := newClient().Purge(context.Background(), ListFilter{}, 0)
+	assert.Error(t, err)
+}
@@ -8,6 +8,7 @@ const (
 	StatusFailed      = "failed"
 	StatusCanceled    = "canceled"
 	StatusInitialized = "initialized"
+	StatusBlocked     = "blocked"
 )
 
 var (
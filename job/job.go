@@ -23,6 +23,10 @@ type Job struct {
 	StartedAt     types.NullTime  `json:"started_at"`
 	CreatedAt     time.Time       `json:"created_at"`
 	UpdadatedAt   time.Time       `json:"updated_at"`
+
+	// DependsOn lists jobs that must complete before this job runs. It is
+	// stored when the job is created and is not loaded by Get or List.
+	DependsOn []string `json:"depends_on,omitempty"`
 }
 
 func (j *Job) ParseArguments(v interface{}) error {
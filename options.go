@@ -2,6 +2,7 @@ package archer
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/dyaksa/archer/job"
@@ -30,6 +31,16 @@ func WithScheduleTime(t time.Time) FnOptions {
 	}
 }
 
+// WithDependsOn keeps the job blocked until every job in ids completed. If one
+// of them fails or is canceled, the job is canceled too. Scheduling fails when
+// a job in ids does not exist.
+func WithDependsOn(ids ...string) FnOptions {
+	return func(j job.Job) job.Job {
+		j.DependsOn = append(j.DependsOn, ids...)
+		return j
+	}
+}
+
 type WorkerOptionFunc func(registerConfig) registerConfig
 
 func WithTimeout(t time.Duration) WorkerOptionFunc {
@@ -46,6 +57,53 @@ func WithInstances(i int) WorkerOptionFunc {
 	}
 }
 
+// WithAutoscale scales the number of worker instances of the queue in this
+// process between min and max, based on the backlog of due jobs and idle
+// polls. It replaces WithInstances; see WithScaleInterval. It panics unless
+// 1 <= min <= max.
+func WithAutoscale(min int, max int) WorkerOptionFunc {
+	if min < 1 || min > max {
+		panic(fmt.Sprintf("archer: WithAutoscale(%d, %d): need 1 <= min <= max", min, max))
+	}
+
+	return func(rc registerConfig) registerConfig {
+		rc.minInstances = min
+		rc.maxInstances = max
+		return rc
+	}
+}
+
+// WithGlobalConcurrency limits how many jobs of the queue run at once across
+// every worker process, unlike WithInstances which counts this process only.
+// The limit is stored when the client starts; see Client.SetGlobalConcurrency.
+func WithGlobalConcurrency(n int) WorkerOptionFunc {
+	return func(rc registerConfig) registerConfig {
+		rc.maxConcurrency = n
+		return rc
+	}
+}
+
+// WithRateLimit limits the queue to n claimed jobs per period across every
+// worker process, using a token bucket stored in the database. Jobs over the
+// limit stay scheduled. The limit is stored when the client starts; see
+// Client.SetRateLimit.
+func WithRateLimit(n int, per time.Duration) WorkerOptionFunc {
+	return func(rc registerConfig) registerConfig {
+		rc.rateLimit = n
+		rc.ratePer = per
+		return rc
+	}
+}
+
+// WithRetention deletes finished jobs of the queue once they are older than
+// the policy allows. See WithJanitorInterval and WithArchiveTable.
+func WithRetention(r Retention) WorkerOptionFunc {
+	return func(rc registerConfig) registerConfig {
+		rc.retention = r
+		return rc
+	}
+}
+
 func WithCallbackSuccess(fn func(ctx context.Context, job job.Job, res any) (any, error)) WorkerOptionFunc {
 	return func(r registerConfig) registerConfig {
 		r.callbackSuccess = fn
@@ -83,6 +141,50 @@ func WithReaperInterval(t time.Duration) ClientOptionFunc {
 	}
 }
 
+// WithScaleInterval sets how often autoscaled queues are resized.
+func WithScaleInterval(t time.Duration) ClientOptionFunc {
+	return func(c *Client) *Client {
+		c.scaleInterval = t
+		return c
+	}
+}
+
+// WithJanitorInterval sets how often retention policies are applied.
+func WithJanitorInterval(t time.Duration) ClientOptionFunc {
+	return func(c *Client) *Client {
+		c.janitorInterval = t
+		return c
+	}
+}
+
+// WithJanitorBatchSize bounds the number of jobs deleted per statement.
+// Values below 1 keep the default.
+func WithJanitorBatchSize(n int) ClientOptionFunc {
+	return func(c *Client) *Client {
+		if n > 0 {
+			c.janitorBatchSize = n
+		}
+		return c
+	}
+}
+
+// WithArchiveTable copies purged jobs to table before deleting them. The table
+// must have the same columns as the jobs table.
+func WithArchiveTable(table string) ClientOptionFunc {
+	return func(c *Client) *Client {
+		c.archiveTable = table
+		return c
+	}
+}
+
+// WithClock replaces the wall clock used for scheduling, retries and reaping.
+func WithClock(clock Clock) ClientOptionFunc {
+	return func(c *Client) *Client {
+		c.clock = clock
+		return c
+	}
+}
+
 func WithErrHandler(fn func(error)) ClientOptionFunc {
 	return func(c *Client) *Client {
 		c.errHandler = fn
@@ -11,12 +11,17 @@ type pool struct {
 	queue         Queue
 	handler       Handler
 	sleepInterval time.Duration
+
+	// stop ends Run after the current job, polled reports every poll; both
+	// are set by the scaler.
+	stop   <-chan struct{}
+	polled func(claimed bool)
 }
 
 func newPool(q *Queue, m mutate, w Worker, sleepInterval time.Duration, callbackSuccess func(ctx context.Context, job job.Job, res any) (any, error), callbackFailed func(ctx context.Context, job job.Job, err error) (any, error)) *pool {
 	return &pool{
 		queue:         *q,
-		handler:       newHandler(w, m, callbackSuccess, callbackFailed),
+		handler:       newHandler(w, m, q.now, callbackSuccess, callbackFailed),
 		sleepInterval: sleepInterval,
 	}
 }
@@ -26,8 +31,13 @@ func (p *pool) Run(ctx context.Context, errChan chan<- error) {
 		select {
 		case <-ctx.Done():
 			return
+		case <-p.stop:
+			return
 		default:
 			j, err := p.queue.Poll(ctx)
+			if p.polled != nil {
+				p.polled(err == nil)
+			}
 			if err == job.ErrorJobNotFound {
 				time.Sleep(p.sleepInterval)
 				continue
@@ -2,15 +2,12 @@ package archer
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"sync"
 	"testing"
 	"time"
 
-	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/dyaksa/archer/job"
-	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -95,18 +92,10 @@ func (m *MockHandler) Handle(ctx context.Context, j job.Job) error {
 }
 
 func TestPool_Run_ContextCancellation(t *testing.T) {
-	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer db.Close()
-
 	queueName := "test_context_cancel_queue"
-	realQueue := NewQueue(db, queueName, "job")
+	realQueue := NewBackendQueue(nil, queueName)
 	mockTx := new(MockTx)
-	realQueue.tx = func(dbtx *sql.Tx) Tx {
-		return mockTx
-	}
+	realQueue.tx = mockTx
 
 	mockHandler := NewMockHandler()
 
@@ -119,14 +108,10 @@ func TestPool_Run_ContextCancellation(t *testing.T) {
 	testJob := &job.Job{ID: "test_job_id", QueueName: queueName}
 
 	// Expectations for the first Poll that returns a job
-	sqlMock.ExpectBegin()
 	mockTx.On("Poll", mock.Anything, queueName).Return(testJob, nil).Once()
-	sqlMock.ExpectCommit()
 
 	// Subsequent Poll calls after cancellation might happen or pool might exit.
 	// If the Poll happens, it should be ErrorJobNotFound.
-	// No separate sqlmock.ExpectBegin/Commit here as the call itself is .Maybe()
-	// and if it doesn't happen, the strict sqlmock expectations would fail.
 	mockTx.On("Poll", mock.Anything, queueName).Return(nil, job.ErrorJobNotFound).Maybe()
 
 	mockHandler.On("Handle", mock.AnythingOfType("*context.cancelCtx"), *testJob).Return(context.Canceled).Once()
@@ -155,7 +140,6 @@ func TestPool_Run_ContextCancellation(t *testing.T) {
 
 	mockHandler.AssertCalled(t, "Handle", mock.AnythingOfType("*context.cancelCtx"), *testJob)
 	mockTx.AssertExpectations(t)
-	assert.NoError(t, sqlMock.ExpectationsWereMet(), "sqlmock expectations were not met")
 
 	select {
 	case err := <-errChan:
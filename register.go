@@ -10,7 +10,13 @@ import (
 type registerConfig struct {
 	w               Worker
 	instances       int
+	minInstances    int
+	maxInstances    int
+	maxConcurrency  int
+	rateLimit       int
+	ratePer         time.Duration
 	timeout         time.Duration
+	retention       Retention
 	callbackSuccess func(ctx context.Context, job job.Job, res any) (any, error)
 	callbackFailed  func(ctx context.Context, job job.Job, err error) (any, error)
 }
@@ -0,0 +1,179 @@
+package archer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+func newScaler(newPool func() *pool, instances int, minInstances int, maxInstances int, every time.Duration, backlog func(ctx context.Context) (int, error)) *scaler {
+	return &scaler{
+		newPool: newPool,
+		target:  instances,
+		min:     minInstances,
+		max:     maxInstances,
+		auto:    maxInstances > 0,
+		ticker:  time.NewTicker(every),
+		backlog: backlog,
+	}
+}
+
+// scaler runs the pool instances of a queue. With autoscaling it keeps
+// between min and max instances: it adds instances while due jobs are waiting
+// and no instance polled idle, and removes one once every instance polled
+// idle with nothing waiting. Removed instances finish their current job.
+type scaler struct {
+	newPool func() *pool
+	min     int
+	max     int
+	ticker  *time.Ticker
+	backlog func(ctx context.Context) (int, error)
+
+	// idle holds the instances, by their stop channel, that polled without
+	// claiming a job since the last autoscale.
+	idleMu sync.Mutex
+	idle   map[chan struct{}]bool
+
+	mu      sync.Mutex
+	target  int
+	auto    bool
+	stops   []chan struct{}
+	ctx     context.Context
+	errChan chan<- error
+	wg      sync.WaitGroup
+}
+
+func (s *scaler) Run(ctx context.Context, errChan chan<- error) {
+	s.mu.Lock()
+	s.ctx, s.errChan = ctx, errChan
+	if s.auto {
+		s.target = min(max(s.target, s.min), s.max)
+	}
+	s.resize()
+	s.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.ctx = nil
+			s.mu.Unlock()
+
+			s.wg.Wait()
+			return
+		case <-s.ticker.C:
+			if err := s.autoscale(ctx); err != nil {
+				errChan <- err
+			}
+		}
+	}
+}
+
+// SetConcurrency runs n instances from now on and turns autoscaling off.
+func (s *scaler) SetConcurrency(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.auto = false
+	s.target = n
+	s.resize()
+}
+
+// Concurrency returns the number of instances the scaler is aiming for.
+func (s *scaler) Concurrency() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.target
+}
+
+func (s *scaler) autoscale(ctx context.Context) error {
+	s.idleMu.Lock()
+	polledIdle := s.idle
+	s.idle = nil
+	s.idleMu.Unlock()
+
+	s.mu.Lock()
+	auto, n := s.auto, s.target
+	idle := 0
+	for _, stop := range s.stops {
+		if polledIdle[stop] {
+			idle++
+		}
+	}
+	s.mu.Unlock()
+
+	if !auto {
+		return nil
+	}
+
+	waiting, err := s.backlog(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case waiting > 0 && idle == 0 && n < s.max:
+		n = min(max(2*n, n+1), n+waiting, s.max)
+	case waiting == 0 && idle >= n && n > s.min:
+		n--
+	default:
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// SetConcurrency may have been called while the backlog was counted.
+	if s.auto {
+		s.target = n
+		s.resize()
+	}
+
+	return nil
+}
+
+// resize starts or stops instances until target are running. It does nothing
+// before Run or after shutdown. s.mu must be held.
+func (s *scaler) resize() {
+	if s.ctx == nil {
+		return
+	}
+
+	for len(s.stops) < s.target {
+		stop := make(chan struct{})
+		s.stops = append(s.stops, stop)
+
+		p := s.newPool()
+		p.stop = stop
+		p.polled = func(claimed bool) {
+			if !claimed {
+				s.markIdle(stop)
+			}
+		}
+
+		s.wg.Add(1)
+		go func(ctx context.Context, errChan chan<- error) {
+			defer s.wg.Done()
+			p.Run(ctx, errChan)
+		}(s.ctx, s.errChan)
+	}
+
+	for len(s.stops) > s.target {
+		last := len(s.stops) - 1
+		close(s.stops[last])
+		s.stops = s.stops[:last]
+	}
+}
+
+// markIdle records that the instance stopped by stop polled without claiming
+// a job.
+func (s *scaler) markIdle(stop chan struct{}) {
+	s.idleMu.Lock()
+	defer s.idleMu.Unlock()
+
+	if s.idle == nil {
+		s.idle = map[chan struct{}]bool{}
+	}
+	s.idle[stop] = true
+}
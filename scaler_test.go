@@ -0,0 +1,76 @@
+package archer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaler_Autoscale(t *testing.T) {
+	ctx := context.Background()
+
+	waiting := 0
+	s := newScaler(nil, 1, 1, 4, time.Hour, func(ctx context.Context) (int, error) {
+		return waiting, nil
+	})
+
+	// instances stand in for running pools, which resize does not start
+	// before Run
+	instances := make([]chan struct{}, 4)
+	for i := range instances {
+		instances[i] = make(chan struct{})
+	}
+	s.stops = instances
+	idle := func(stops ...chan struct{}) {
+		for _, stop := range stops {
+			s.markIdle(stop)
+		}
+	}
+
+	waiting = 10
+	assert.NoError(t, s.autoscale(ctx))
+	assert.Equal(t, 2, s.Concurrency(), "jobs waiting and no idle polls")
+	assert.NoError(t, s.autoscale(ctx))
+	assert.Equal(t, 4, s.Concurrency())
+	assert.NoError(t, s.autoscale(ctx))
+	assert.Equal(t, 4, s.Concurrency(), "capped at max")
+
+	waiting = 3
+	idle(instances[:2]...)
+	assert.NoError(t, s.autoscale(ctx))
+	assert.Equal(t, 4, s.Concurrency(), "some instances idle")
+
+	waiting = 0
+	for i := 0; i < 4; i++ {
+		idle(instances[0])
+	}
+	assert.NoError(t, s.autoscale(ctx))
+	assert.Equal(t, 4, s.Concurrency(), "one instance idle many times")
+
+	idle(instances...)
+	assert.NoError(t, s.autoscale(ctx))
+	assert.Equal(t, 3, s.Concurrency(), "every instance idle")
+
+	s.SetConcurrency(2)
+	idle(instances...)
+	assert.NoError(t, s.autoscale(ctx))
+	assert.Equal(t, 2, s.Concurrency(), "fixed after SetConcurrency")
+}
+
+func TestScaler_AutoscaleBacklogError(t *testing.T) {
+	s := newScaler(nil, 1, 1, 4, time.Hour, func(ctx context.Context) (int, error) {
+		return 0, errors.New("db down")
+	})
+
+	assert.Error(t, s.autoscale(context.Background()))
+	assert.Equal(t, 1, s.Concurrency())
+}
+
+func TestWithAutoscaleBounds(t *testing.T) {
+	assert.Panics(t, func() { WithAutoscale(0, 4) })
+	assert.Panics(t, func() { WithAutoscale(5, 4) })
+	assert.NotPanics(t, func() { WithAutoscale(2, 2) })
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/dyaksa/archer/job"
+)
+
+// Clock tells the current time. Backends use it for timestamps and to decide
+// which jobs are due, instead of the database clock.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Backend is the storage used to persist, claim and mutate jobs. Postgres is
+// the default implementation; other databases can be plugged in by
+// implementing this interface. Listing, bulk changes, purging, stats and
+// queue controls are optional; see Lister, Editor, BulkEditor, Purger,
+// StatsReader and QueueController.
+type Backend interface {
+	// Create inserts a new job.
+	Create(ctx context.Context, job job.Job) error
+	// Get returns the job with the given id or job.ErrorJobNotFound.
+	Get(ctx context.Context, id string) (*job.Job, error)
+	// Poll claims the oldest scheduled job of queueName that is due and marks
+	// it as initialized. It returns job.ErrorJobNotFound when nothing is ready.
+	Poll(ctx context.Context, queueName string) (*job.Job, error)
+	// Update persists the status, result, error and schedule of a job.
+	Update(ctx context.Context, job job.Job) error
+	// RequeueTimeout reschedules initialized jobs of queueName that were
+	// started before timeout.
+	RequeueTimeout(ctx context.Context, queueName string, timeout time.Time) error
+	// Search returns jobs whose id contains search, newest first.
+	Search(ctx context.Context, limit int, offset int, search string) ([]*job.Job, error)
+	// Deschedule cancels a job that is still scheduled.
+	Deschedule(ctx context.Context, id string) error
+	// ScheduleNow makes a job due immediately. Jobs blocked by dependencies
+	// are left alone.
+	ScheduleNow(ctx context.Context, id string) error
+}
+
+// Lister is implemented by backends that can page through jobs. Client.List,
+// autoscaling and workflow inspection require it.
+type Lister interface {
+	// List returns a page of jobs matching the filter, newest first.
+	List(ctx context.Context, filter ListFilter) (ListResult, error)
+}
+
+// Editor is implemented by backends that can move or remove single jobs.
+type Editor interface {
+	// Reschedule moves a job that is not running to at.
+	Reschedule(ctx context.Context, id string, at time.Time) error
+	// Delete removes a job.
+	Delete(ctx context.Context, id string) error
+}
+
+// BulkEditor is implemented by backends that can retry or cancel every job
+// matching a filter.
+type BulkEditor interface {
+	// RetryWhere reschedules every job matching filter that is not running
+	// to run now with a reset retry count, and returns how many were changed.
+	// Jobs with dependencies that have not completed are blocked or, while
+	// one failed or was canceled, canceled again.
+	RetryWhere(ctx context.Context, filter ListFilter) (int, error)
+	// CancelWhere cancels every scheduled job matching filter and returns how
+	// many were changed.
+	CancelWhere(ctx context.Context, filter ListFilter) (int, error)
+}
+
+// Purger is implemented by backends that can delete finished jobs in
+// batches. Client.Purge and the janitor require it.
+type Purger interface {
+	// Purge deletes up to limit jobs matching filter, least recently updated
+	// first, and returns how many were deleted. When archiveTable is set the
+	// jobs are copied to it before they are deleted.
+	Purge(ctx context.Context, filter ListFilter, limit int, archiveTable string) (int, error)
+}
+
+// StatsReader is implemented by backends that can count jobs per queue.
+type StatsReader interface {
+	// Stats counts jobs per queue and status, ordered by queue name.
+	Stats(ctx context.Context) ([]QueueStats, error)
+}
+
+// QueueController is implemented by backends that store per-queue state
+// shared by every worker process.
+type QueueController interface {
+	// SetQueuePaused pauses or resumes claiming jobs from a queue. The state
+	// is stored next to the jobs, so it applies to every worker process.
+	SetQueuePaused(ctx context.Context, queueName string, paused bool) error
+	// SetQueueConcurrency limits how many jobs of a queue may be running
+	// across all worker processes. Poll claims nothing while the limit is
+	// reached. Zero removes the limit.
+	SetQueueConcurrency(ctx context.Context, queueName string, n int) error
+	// SetQueueRateLimit limits claims from a queue to n per period across all
+	// worker processes. Poll claims nothing while the queue's token bucket is
+	// empty. Zero n removes the limit.
+	SetQueueRateLimit(ctx context.Context, queueName string, n int, per time.Duration) error
+}
+
+// QueueStats counts the jobs of a queue by status.
+type QueueStats struct {
+	Paused         bool
+	MaxConcurrency int
+	RateLimit      int
+	RatePeriod     time.Duration
+
+	Queue  string
+	Counts map[string]int
+}
+
+// Total returns the number of jobs in the queue.
+func (s QueueStats) Total() int {
+	total := 0
+	for _, n := range s.Counts {
+		total += n
+	}
+	return total
+}
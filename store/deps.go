@@ -0,0 +1,199 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dyaksa/archer/job"
+)
+
+// depsTable holds one row per dependency of a job on a parent job.
+func depsTable(tableName string) string {
+	return tableName + "_deps"
+}
+
+// dependencyStatus returns the status a new job with parents starts in:
+// blocked until every parent completed, or canceled with an error when a
+// parent already failed or was canceled. Parent rows are read with lock
+// appended to the query, which must stop them from finishing until the new
+// job is committed.
+func dependencyStatus(ctx context.Context, tx *sql.Tx, tableName string, j job.Job, w *whereBuilder, lock string) (string, string, error) {
+	if len(j.DependsOn) == 0 {
+		return j.Status, j.LastError, nil
+	}
+
+	marks := make([]string, len(j.DependsOn))
+	for i, id := range j.DependsOn {
+		marks[i] = w.param(id)
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, status FROM `+tableName+`
+	WHERE id IN (`+strings.Join(marks, ", ")+`)`+lock, w.args...)
+	if err != nil {
+		return "", "", err
+	}
+
+	defer rows.Close()
+
+	statuses := map[string]string{}
+	for rows.Next() {
+		var id, status string
+		if err := rows.Scan(&id, &status); err != nil {
+			return "", "", err
+		}
+		statuses[id] = status
+	}
+
+	if err := rows.Err(); err != nil {
+		return "", "", err
+	}
+
+	return resolveDependencies(j, statuses)
+}
+
+// resolveDependencies decides the initial status of j from the statuses of
+// its parents.
+func resolveDependencies(j job.Job, parents map[string]string) (string, string, error) {
+	status := j.Status
+	for _, id := range j.DependsOn {
+		switch parents[id] {
+		case "":
+			return "", "", fmt.Errorf("dependency %s: %w", id, job.ErrorJobNotFound)
+		case job.StatusFailed, job.StatusCanceled:
+			return job.StatusCanceled, dependencyError(id, parents[id]), nil
+		case job.StatusCompleted:
+		default:
+			status = job.StatusBlocked
+		}
+	}
+
+	return status, j.LastError, nil
+}
+
+func dependencyError(id string, status string) string {
+	return "dependency " + id + " " + status
+}
+
+func insertDependencies(ctx context.Context, tx *sql.Tx, tableName string, j job.Job, where func() *whereBuilder) error {
+	if len(j.DependsOn) == 0 {
+		return nil
+	}
+
+	// rows left behind by a purged job with the same id
+	w := where()
+	if err := exec(ctx, tx, `DELETE FROM `+depsTable(tableName)+` WHERE job_id = `+w.param(j.ID), w.args...); err != nil {
+		return err
+	}
+
+	w = where()
+	values := make([]string, len(j.DependsOn))
+	for i, id := range j.DependsOn {
+		values[i] = `(` + w.param(j.ID) + `, ` + w.param(id) + `)`
+	}
+
+	return exec(ctx, tx, `INSERT INTO `+depsTable(tableName)+` (job_id, depends_on)
+	VALUES `+strings.Join(values, ", ")+`
+	ON CONFLICT DO NOTHING`, w.args...)
+}
+
+// deleteEdges returns a statement deleting the dependency rows of the jobs
+// selected by ids, both as dependent and as parent. ids is called once per
+// use, so it can bind its own arguments.
+func deleteEdges(tableName string, ids func() string) string {
+	return `DELETE FROM ` + depsTable(tableName) + `
+	WHERE job_id IN (` + ids() + `) OR depends_on IN (` + ids() + `)`
+}
+
+// deleteJob deletes job id and its dependency rows. Its blocked dependents
+// are settled as if it had completed: they are released once their other
+// parents completed and canceled when one of those failed, so deleting an
+// unfinished parent does not leave them blocked for good. lock is passed on
+// to releaseDependents.
+func deleteJob(ctx context.Context, tx *sql.Tx, tableName string, id string, where func() *whereBuilder, now time.Time, lock string) error {
+	w := where()
+	if err := exec(ctx, tx, `DELETE FROM `+tableName+` WHERE id = `+w.param(id), w.args...); err != nil {
+		return err
+	}
+
+	// The deleted job no longer counts as an unfinished parent.
+	if err := releaseDependents(ctx, tx, tableName, id, where, now, lock); err != nil {
+		return err
+	}
+
+	if err := cancelDependents(ctx, tx, tableName, where, now); err != nil {
+		return err
+	}
+
+	w = where()
+	return exec(ctx, tx, deleteEdges(tableName, func() string { return w.param(id) }), w.args...)
+}
+
+// settleDependents moves blocked dependents of a job that reached status
+// forward: they are scheduled once every parent completed, and canceled when
+// the job failed or was canceled. A non-empty lock locks the dependents
+// before their parents are checked; see releaseDependents.
+func settleDependents(ctx context.Context, tx *sql.Tx, tableName string, id string, status string, where func() *whereBuilder, now time.Time, lock string) error {
+	switch status {
+	case job.StatusCompleted:
+		return releaseDependents(ctx, tx, tableName, id, where, now, lock)
+	case job.StatusFailed, job.StatusCanceled:
+		return cancelDependents(ctx, tx, tableName, where, now)
+	}
+
+	return nil
+}
+
+// releaseDependents schedules the blocked dependents of id whose parents all
+// completed. When two parents complete at once, neither sees the other's
+// uncommitted status, so with lock set the dependents are locked first: the
+// transaction that waits for the lock checks the parents with a snapshot
+// taken after the other one committed.
+func releaseDependents(ctx context.Context, tx *sql.Tx, tableName string, id string, where func() *whereBuilder, now time.Time, lock string) error {
+	if lock != "" {
+		w := where()
+		err := exec(ctx, tx, `SELECT id FROM `+tableName+`
+		WHERE status = `+w.param(job.StatusBlocked)+`
+			AND id IN (SELECT job_id FROM `+depsTable(tableName)+` WHERE depends_on = `+w.param(id)+`)
+		ORDER BY id`+lock, w.args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	w := where()
+	return exec(ctx, tx, `UPDATE `+tableName+`
+	SET status = `+w.param(job.StatusScheduled)+`, updated_at = `+w.param(w.timeArg(now))+`
+	WHERE status = `+w.param(job.StatusBlocked)+`
+		AND id IN (SELECT job_id FROM `+depsTable(tableName)+` WHERE depends_on = `+w.param(id)+`)
+		AND NOT EXISTS (
+			SELECT 1 FROM `+depsTable(tableName)+` d
+			JOIN `+tableName+` p ON p.id = d.depends_on
+			WHERE d.job_id = `+tableName+`.id AND p.status <> `+w.param(job.StatusCompleted)+`
+		)`, w.args...)
+}
+
+// cancelDependents cancels blocked jobs with a failed or canceled parent,
+// repeating until chains of dependents are canceled too.
+func cancelDependents(ctx context.Context, tx *sql.Tx, tableName string, where func() *whereBuilder, now time.Time) error {
+	for {
+		w := where()
+		// parent binds its own arguments, as "?" placeholders cannot be reused.
+		parent := func() string {
+			return `SELECT p.id || ' ' || p.status FROM ` + depsTable(tableName) + ` d
+			JOIN ` + tableName + ` p ON p.id = d.depends_on
+			WHERE d.job_id = ` + tableName + `.id AND p.status IN (` + w.param(job.StatusFailed) + `, ` + w.param(job.StatusCanceled) + `)`
+		}
+
+		n, err := execAffected(ctx, tx, `UPDATE `+tableName+`
+		SET status = `+w.param(job.StatusCanceled)+`,
+			last_error = 'dependency ' || (`+parent()+` LIMIT 1),
+			updated_at = `+w.param(w.timeArg(now))+`
+		WHERE status = `+w.param(job.StatusBlocked)+` AND EXISTS (`+parent()+`)`, w.args...)
+		if err != nil || n == 0 {
+			return err
+		}
+	}
+}
@@ -5,6 +5,16 @@ import (
 	"database/sql"
 )
 
+func execAffected(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (int, error) {
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
 func exec(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) error {
 	_, err := tx.ExecContext(ctx, query, args...)
 	return err
@@ -0,0 +1,205 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dyaksa/archer/job"
+)
+
+const defaultListLimit = 50
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListFilter selects jobs for listing and bulk operations. Zero values are
+// ignored, "After" bounds are inclusive and "Before" bounds are exclusive.
+// Cursor and Limit only apply to List.
+type ListFilter struct {
+	Queue           string
+	Statuses        []string
+	ID              string
+	IDPrefix        string
+	CreatedAfter    time.Time
+	CreatedBefore   time.Time
+	ScheduledAfter  time.Time
+	ScheduledBefore time.Time
+	UpdatedAfter    time.Time
+	UpdatedBefore   time.Time
+
+	// Cursor continues a listing from ListResult.Next.
+	Cursor string
+	// Limit is the page size, 50 when not set.
+	Limit int
+}
+
+// ListResult is a page of jobs ordered by creation time, newest first.
+type ListResult struct {
+	Jobs []job.Job
+	// Total is the number of jobs matching the filter across all pages.
+	Total int
+	// Next is the cursor of the following page, empty on the last page.
+	Next string
+}
+
+// PageSize returns Limit or the default page size.
+func (f ListFilter) PageSize() int {
+	if f.Limit <= 0 {
+		return defaultListLimit
+	}
+	return f.Limit
+}
+
+// Match reports whether j satisfies the filter, ignoring the cursor.
+func (f ListFilter) Match(j job.Job) bool {
+	if f.Queue != "" && j.QueueName != f.Queue {
+		return false
+	}
+
+	if len(f.Statuses) > 0 && !contains(f.Statuses, j.Status) {
+		return false
+	}
+
+	if f.ID != "" && j.ID != f.ID {
+		return false
+	}
+
+	if f.IDPrefix != "" && !strings.HasPrefix(j.ID, f.IDPrefix) {
+		return false
+	}
+
+	if !inRange(j.CreatedAt, f.CreatedAfter, f.CreatedBefore) {
+		return false
+	}
+
+	if !inRange(j.ScheduleAt, f.ScheduledAfter, f.ScheduledBefore) {
+		return false
+	}
+
+	return inRange(j.UpdadatedAt, f.UpdatedAfter, f.UpdatedBefore)
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func inRange(t time.Time, after time.Time, before time.Time) bool {
+	if !after.IsZero() && t.Before(after) {
+		return false
+	}
+
+	if !before.IsZero() && !t.Before(before) {
+		return false
+	}
+
+	return true
+}
+
+// EncodeCursor returns the cursor of the page following j.
+func EncodeCursor(j job.Job) string {
+	raw := strconv.FormatInt(j.CreatedAt.UnixNano(), 10) + ":" + j.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor returns the creation time and id encoded by EncodeCursor.
+func DecodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	return time.Unix(0, n), id, nil
+}
+
+// whereBuilder renders filters for SQL backends. placeholder renders the
+// n-th bind parameter and timeArg converts times to the stored format.
+type whereBuilder struct {
+	placeholder func(n int) string
+	timeArg     func(t time.Time) any
+
+	conds []string
+	args  []any
+}
+
+// param binds arg and returns its placeholder.
+func (w *whereBuilder) param(arg any) string {
+	w.args = append(w.args, arg)
+	return w.placeholder(len(w.args))
+}
+
+func (w *whereBuilder) add(cond string, args ...any) {
+	for _, arg := range args {
+		cond = strings.Replace(cond, "?", w.param(arg), 1)
+	}
+	w.conds = append(w.conds, cond)
+}
+
+func (w *whereBuilder) filter(f ListFilter) {
+	if f.Queue != "" {
+		w.add("queue_name = ?", f.Queue)
+	}
+
+	if len(f.Statuses) > 0 {
+		marks := make([]string, len(f.Statuses))
+		args := make([]any, len(f.Statuses))
+		for i, s := range f.Statuses {
+			marks[i] = "?"
+			args[i] = s
+		}
+		w.add("status IN ("+strings.Join(marks, ", ")+")", args...)
+	}
+
+	if f.ID != "" {
+		w.add("id = ?", f.ID)
+	}
+
+	if f.IDPrefix != "" {
+		w.add(`id LIKE ? ESCAPE '\'`, escapeLike(f.IDPrefix)+"%")
+	}
+
+	w.timeRange("created_at", f.CreatedAfter, f.CreatedBefore)
+	w.timeRange("scheduled_at", f.ScheduledAfter, f.ScheduledBefore)
+	w.timeRange("updated_at", f.UpdatedAfter, f.UpdatedBefore)
+}
+
+func (w *whereBuilder) timeRange(column string, after time.Time, before time.Time) {
+	if !after.IsZero() {
+		w.add(column+" >= ?", w.timeArg(after))
+	}
+
+	if !before.IsZero() {
+		w.add(column+" < ?", w.timeArg(before))
+	}
+}
+
+func (w *whereBuilder) cursor(createdAt time.Time, id string) {
+	w.add("(created_at, id) < (?, ?)", w.timeArg(createdAt), id)
+}
+
+func (w *whereBuilder) String() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conds, " AND ")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
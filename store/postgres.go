@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Postgres is the default Backend. Every call runs in its own transaction.
+type Postgres struct {
+	wrappedBackend
+	tableName string
+}
+
+func NewPostgres(db *sql.DB, tableName string) *Postgres {
+	p := &Postgres{tableName: tableName}
+	p.wrappedBackend = wrappedBackend{
+		WrapperTx: *NewWrapperTx(db),
+		tx:        p.bind,
+		clock:     systemClock{},
+	}
+
+	return p
+}
+
+// WithTx returns a Backend bound to a transaction owned by the caller, so jobs
+// can be written atomically with the caller's own changes.
+func (p *Postgres) WithTx(tx *sql.Tx) Backend {
+	return p.bind(tx)
+}
+
+func (p *Postgres) bind(tx *sql.Tx) fullBackend {
+	return &Tx{Tx: tx, tableName: p.tableName, now: p.clock.Now}
+}
+
+// Migrate creates the jobs table, its indexes, the queue state table and the
+// job dependency table when they do not exist, and upgrades tables created
+// by earlier versions.
+func (p *Postgres) Migrate(ctx context.Context) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS ` + p.tableName + ` (
+			id varchar primary key,
+			queue_name varchar not null,
+			status varchar not null,
+			arguments jsonb not null default '{}'::jsonb,
+			result jsonb not null default '{}'::jsonb,
+			last_error varchar,
+			retry_count integer not null default 0,
+			max_retry integer not null default 0,
+			retry_interval bigint not null default 0,
+			scheduled_at timestamptz default now(),
+			started_at timestamptz,
+			created_at timestamptz not null default now(),
+			updated_at timestamptz not null default now()
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + p.tableName + `_queue_name_idx ON ` + p.tableName + ` (queue_name)`,
+		`CREATE INDEX IF NOT EXISTS ` + p.tableName + `_scheduled_at_idx ON ` + p.tableName + ` (scheduled_at)`,
+		`CREATE INDEX IF NOT EXISTS ` + p.tableName + `_status_idx ON ` + p.tableName + ` (status)`,
+		`CREATE INDEX IF NOT EXISTS ` + p.tableName + `_started_at_idx ON ` + p.tableName + ` (started_at)`,
+		`CREATE TABLE IF NOT EXISTS ` + queuesTable(p.tableName) + ` (
+			queue_name varchar primary key,
+			paused boolean not null default false,
+			max_concurrency integer not null default 0,
+			rate_limit integer not null default 0,
+			rate_period bigint not null default 0,
+			rate_tokens double precision not null default 0,
+			rate_updated_at bigint not null default 0,
+			updated_at timestamptz not null default now()
+		)`,
+		`CREATE TABLE IF NOT EXISTS ` + depsTable(p.tableName) + ` (
+			job_id varchar not null,
+			depends_on varchar not null,
+			primary key (job_id, depends_on)
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + depsTable(p.tableName) + `_depends_on_idx ON ` + depsTable(p.tableName) + ` (depends_on)`,
+	}
+
+	_, err := p.WrapTx(ctx, func(ctx context.Context, tx *sql.Tx) (any, error) {
+		for _, stmt := range statements {
+			if err := exec(ctx, tx, stmt); err != nil {
+				return nil, err
+			}
+		}
+		return nil, p.widenRetryInterval(ctx, tx)
+	})
+
+	return err
+}
+
+// widenRetryInterval changes retry_interval of tables created by earlier
+// versions from integer to bigint. The ALTER locks the whole table, so it
+// only runs when the column still has the old type.
+func (p *Postgres) widenRetryInterval(ctx context.Context, tx *sql.Tx) error {
+	var typ string
+	err := tx.QueryRowContext(ctx, `SELECT format_type(atttypid, atttypmod) FROM pg_attribute
+	WHERE attrelid = $1::regclass AND attname = 'retry_interval'`, p.tableName).Scan(&typ)
+	if err != nil || typ == "bigint" {
+		return err
+	}
+
+	return exec(ctx, tx, `ALTER TABLE `+p.tableName+` ALTER COLUMN retry_interval TYPE bigint`)
+}
@@ -0,0 +1,305 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/dyaksa/archer/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func jobRows() *sqlmock.Rows {
+	return sqlmock.NewRows(columns)
+}
+
+func TestPostgresPoll(t *testing.T) {
+	t.Run("claimed", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		now := time.Now()
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`SELECT rate_limit, rate_period, rate_tokens, rate_updated_at\s+FROM jobs_queues\s+WHERE queue_name = \$1 AND \(max_concurrency > 0 OR rate_limit > 0\) FOR UPDATE`).
+			WithArgs("emails").
+			WillReturnRows(sqlmock.NewRows([]string{"rate_limit", "rate_period", "rate_tokens", "rate_updated_at"}))
+		sqlMock.ExpectQuery("UPDATE jobs").
+			WithArgs(job.StatusInitialized, sqlmock.AnyArg(), job.StatusScheduled, "emails").
+			WillReturnRows(jobRows().AddRow("j1", "emails", job.StatusInitialized, nil, 0, 3, []byte(`{}`), nil, 0, now, now, now, now))
+		sqlMock.ExpectCommit()
+
+		j, err := NewPostgres(db, "jobs").Poll(context.Background(), "emails")
+		assert.NoError(t, err)
+		assert.Equal(t, "j1", j.ID)
+		assert.Equal(t, 3, j.MaxRetry)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`SELECT rate_limit, rate_period, rate_tokens, rate_updated_at\s+FROM jobs_queues\s+WHERE queue_name = \$1 AND \(max_concurrency > 0 OR rate_limit > 0\) FOR UPDATE`).
+			WithArgs("emails").
+			WillReturnRows(sqlmock.NewRows([]string{"rate_limit", "rate_period", "rate_tokens", "rate_updated_at"}))
+		sqlMock.ExpectQuery("UPDATE jobs").WillReturnRows(jobRows())
+		sqlMock.ExpectRollback()
+
+		_, err = NewPostgres(db, "jobs").Poll(context.Background(), "emails")
+		assert.ErrorIs(t, err, job.ErrorJobNotFound)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresWithTx(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec("INSERT INTO outbox").WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewPostgres(db, "outbox").WithTx(tx)
+	assert.NoError(t, b.Create(context.Background(), job.Job{ID: "j1", QueueName: "emails"}))
+	assert.NoError(t, tx.Commit())
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestPostgresClock(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	timeout := now.Add(-time.Minute)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec("UPDATE jobs").
+		WithArgs(job.StatusScheduled, now, timeout, job.StatusInitialized, "emails").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	p := NewPostgres(db, "jobs")
+	p.SetClock(fixedClock(now))
+
+	assert.NoError(t, p.RequeueTimeout(context.Background(), "emails", timeout))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestPostgresList(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(`SELECT count\(\*\) FROM jobs WHERE queue_name = \$1 AND status IN \(\$2, \$3\)`).
+		WithArgs("emails", job.StatusFailed, job.StatusCanceled).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	sqlMock.ExpectQuery(`WHERE queue_name = \$1 AND status IN \(\$2, \$3\) AND \(created_at, id\) < \(\$4, \$5\)\s+ORDER BY created_at DESC, id DESC\s+LIMIT 2`).
+		WithArgs("emails", job.StatusFailed, job.StatusCanceled, sqlmock.AnyArg(), "j3").
+		WillReturnRows(jobRows().
+			AddRow("j2", "emails", job.StatusFailed, nil, 0, 0, []byte(`{}`), nil, 0, now, nil, now, now).
+			AddRow("j1", "emails", job.StatusFailed, nil, 0, 0, []byte(`{}`), nil, 0, now, nil, now, now))
+	sqlMock.ExpectCommit()
+
+	res, err := NewPostgres(db, "jobs").List(context.Background(), ListFilter{
+		Queue:    "emails",
+		Statuses: []string{job.StatusFailed, job.StatusCanceled},
+		Cursor:   EncodeCursor(job.Job{ID: "j3", CreatedAt: now}),
+		Limit:    1,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, res.Total)
+	assert.Len(t, res.Jobs, 1)
+	assert.Equal(t, "j2", res.Jobs[0].ID)
+	assert.NotEmpty(t, res.Next)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestPostgresRetryWhere(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	after := now.Add(-time.Hour)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`UPDATE jobs SET status=CASE WHEN EXISTS \(\s+SELECT 1 FROM jobs_deps d\s+JOIN jobs p ON p.id = d.depends_on\s+WHERE d.job_id = jobs.id AND p.status <> \$1\s+\) THEN \$2 ELSE \$3 END,\s+retry_count=0,\s+last_error=null,\s+started_at=null,\s+scheduled_at=\$4,\s+updated_at=\$5 WHERE queue_name = \$6 AND status IN \(\$7\) AND created_at >= \$8 AND status NOT IN \(\$9, \$10\)`).
+		WithArgs(job.StatusCompleted, job.StatusBlocked, job.StatusScheduled, now, now, "emails", job.StatusFailed, after, job.StatusInitialized, job.StatusBlocked).
+		WillReturnResult(sqlmock.NewResult(0, 42))
+	sqlMock.ExpectExec(`UPDATE jobs\s+SET status = \$1,\s+last_error`).WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectCommit()
+
+	p := NewPostgres(db, "jobs")
+	p.SetClock(fixedClock(now))
+
+	n, err := p.RetryWhere(context.Background(), ListFilter{Queue: "emails", Statuses: []string{job.StatusFailed}, CreatedAfter: after})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, n)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestPostgresPurge(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	before := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`WITH purged AS \(\s+DELETE FROM jobs WHERE id IN \(SELECT id FROM jobs WHERE queue_name = \$1 AND status IN \(\$2\) AND updated_at < \$3\s+ORDER BY updated_at, id\s+LIMIT 500\)\s+RETURNING .+\)\s+INSERT INTO jobs_archive`).
+		WithArgs("emails", job.StatusCompleted, before).
+		WillReturnResult(sqlmock.NewResult(0, 500))
+	sqlMock.ExpectCommit()
+
+	p := NewPostgres(db, "jobs")
+
+	n, err := p.Purge(context.Background(), ListFilter{Queue: "emails", Statuses: []string{job.StatusCompleted}, UpdatedBefore: before}, 500, "jobs_archive")
+	assert.NoError(t, err)
+	assert.Equal(t, 500, n)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestPostgresCreateWithDependencies(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(`SELECT id, status FROM jobs\s+WHERE id IN \(\$1, \$2\) FOR SHARE`).
+		WithArgs("a", "b").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("a", job.StatusCompleted).AddRow("b", job.StatusScheduled))
+	sqlMock.ExpectExec("INSERT INTO jobs").
+		WithArgs("c", "q", job.StatusBlocked, sqlmock.AnyArg(), "", 0, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(`DELETE FROM jobs_deps WHERE job_id = \$1`).WithArgs("c").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec(`INSERT INTO jobs_deps \(job_id, depends_on\)\s+VALUES \(\$1, \$2\), \(\$3, \$4\)`).
+		WithArgs("c", "a", "c", "b").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectCommit()
+
+	err = NewPostgres(db, "jobs").Create(context.Background(), job.Job{ID: "c", QueueName: "q", Status: job.StatusScheduled, DependsOn: []string{"a", "b"}})
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestPostgresUpdateReleasesDependents(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`UPDATE jobs\s+SET\s+status=\$1`).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(`SELECT id FROM jobs\s+WHERE status = \$1\s+AND id IN \(SELECT job_id FROM jobs_deps WHERE depends_on = \$2\)\s+ORDER BY id FOR UPDATE`).
+		WithArgs(job.StatusBlocked, "a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(`UPDATE jobs\s+SET status = \$1`).
+		WithArgs(job.StatusScheduled, sqlmock.AnyArg(), job.StatusBlocked, "a", job.StatusCompleted).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	err = NewPostgres(db, "jobs").Update(context.Background(), job.Job{ID: "a", QueueName: "q", Status: job.StatusCompleted})
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestPostgresDeleteDependencies(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`DELETE FROM jobs WHERE id = \$1`).WithArgs("a").WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(`SELECT id FROM jobs\s+WHERE status = \$1\s+AND id IN \(SELECT job_id FROM jobs_deps WHERE depends_on = \$2\)\s+ORDER BY id FOR UPDATE`).
+		WithArgs(job.StatusBlocked, "a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(`UPDATE jobs\s+SET status = \$1`).
+		WithArgs(job.StatusScheduled, sqlmock.AnyArg(), job.StatusBlocked, "a", job.StatusCompleted).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(`UPDATE jobs\s+SET status = \$1,\s+last_error`).
+		WithArgs(job.StatusCanceled, job.StatusFailed, job.StatusCanceled, sqlmock.AnyArg(), job.StatusBlocked, job.StatusFailed, job.StatusCanceled).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec(`DELETE FROM jobs_deps\s+WHERE job_id IN \(\$1\) OR depends_on IN \(\$2\)`).
+		WithArgs("a", "a").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectCommit()
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(`WITH purged AS \(.+\), edges AS \(\s+DELETE FROM jobs_deps\s+WHERE job_id IN \(SELECT id FROM purged\) OR depends_on IN \(SELECT id FROM purged\)\s+\) SELECT count\(\*\) FROM purged`).
+		WithArgs("emails").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	sqlMock.ExpectCommit()
+
+	p := NewPostgres(db, "jobs")
+	assert.NoError(t, p.Delete(context.Background(), "a"))
+
+	n, err := p.Purge(context.Background(), ListFilter{Queue: "emails"}, 100, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestPostgresMigrate(t *testing.T) {
+	for _, typ := range []string{"bigint", "integer"} {
+		t.Run(typ, func(t *testing.T) {
+			db, sqlMock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			sqlMock.ExpectBegin()
+			for i := 0; i < 8; i++ {
+				sqlMock.ExpectExec("CREATE").WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+			sqlMock.ExpectQuery(`SELECT format_type\(atttypid, atttypmod\) FROM pg_attribute`).
+				WithArgs("jobs").
+				WillReturnRows(sqlmock.NewRows([]string{"format_type"}).AddRow(typ))
+			if typ != "bigint" {
+				sqlMock.ExpectExec(`ALTER TABLE jobs ALTER COLUMN retry_interval TYPE bigint`).WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+			sqlMock.ExpectCommit()
+
+			assert.NoError(t, NewPostgres(db, "jobs").Migrate(context.Background()))
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
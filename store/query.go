@@ -4,13 +4,26 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dyaksa/archer/job"
 )
 
 func queryJob(ctx context.Context, tx *sql.Tx, query string, args ...any) (*job.Job, error) {
+	return queryJobWith(ctx, tx, (*entity).ScanDestinations, query, args...)
+}
+
+func queryJobs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]*job.Job, error) {
+	return queryJobsWith(ctx, tx, (*entity).ScanDestinations, query, args...)
+}
+
+func queryJobWith(ctx context.Context, tx *sql.Tx, dest func(*entity) []interface{}, query string, args ...any) (*job.Job, error) {
 	e := new(entity)
-	err := tx.QueryRowContext(ctx, query, args...).Scan(e.ScanDestinations()...)
+	err := tx.QueryRowContext(ctx, query, args...).Scan(dest(e)...)
 
 	switch {
 	case errors.Is(err, sql.ErrNoRows):
@@ -22,7 +35,7 @@ func queryJob(ctx context.Context, tx *sql.Tx, query string, args ...any) (*job.
 	}
 }
 
-func queryJobs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]*job.Job, error) {
+func queryJobsWith(ctx context.Context, tx *sql.Tx, dest func(*entity) []interface{}, query string, args ...any) ([]*job.Job, error) {
 	entities := []*job.Job{}
 	rows, err := tx.QueryContext(ctx, query, args...)
 
@@ -34,7 +47,7 @@ func queryJobs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]*j
 
 	for rows.Next() {
 		e := new(entity)
-		if err := rows.Scan(e.ScanDestinations()...); err != nil {
+		if err := rows.Scan(dest(e)...); err != nil {
 			return nil, err
 		}
 		entities = append(entities, e.To())
@@ -46,3 +59,229 @@ func queryJobs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]*j
 
 	return entities, nil
 }
+
+// queuesTable holds per queue state shared by every worker process.
+func queuesTable(tableName string) string {
+	return tableName + "_queues"
+}
+
+// setQueueState upserts columns of a queue's state row.
+func setQueueState(ctx context.Context, tx *sql.Tx, tableName string, queueName string, w *whereBuilder, now time.Time, columns []string, values ...any) error {
+	marks := []string{w.param(queueName)}
+	set := []string{}
+	for i, column := range columns {
+		marks = append(marks, w.param(values[i]))
+		set = append(set, column+` = excluded.`+column)
+	}
+	marks = append(marks, w.param(w.timeArg(now)))
+	set = append(set, `updated_at = excluded.updated_at`)
+
+	return exec(ctx, tx, `INSERT INTO `+queuesTable(tableName)+` (queue_name, `+strings.Join(columns, ", ")+`, updated_at)
+	VALUES (`+strings.Join(marks, ", ")+`)
+	ON CONFLICT (queue_name) DO UPDATE SET `+strings.Join(set, ", "), w.args...)
+}
+
+// pollLimited runs claim under the queue's rate limit. The state row is read
+// with lock appended to the query, which must keep concurrent pollers from
+// taking the same token; the token is only spent when a job is claimed.
+func pollLimited(ctx context.Context, tx *sql.Tx, tableName string, queueName string, where func() *whereBuilder, lock string, now time.Time, claim func() (*job.Job, error)) (*job.Job, error) {
+	w := where()
+	var bucket RateLimit
+	var per, updatedAt int64
+	err := tx.QueryRowContext(ctx, `SELECT rate_limit, rate_period, rate_tokens, rate_updated_at
+	FROM `+queuesTable(tableName)+`
+	WHERE queue_name = `+w.param(queueName)+` AND (max_concurrency > 0 OR rate_limit > 0)`+lock, w.args...).
+		Scan(&bucket.Limit, &per, &bucket.Tokens, &updatedAt)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return claim()
+	case err != nil:
+		return &job.Job{}, err
+	case bucket.Limit <= 0:
+		return claim()
+	}
+
+	bucket.Per = time.Duration(per)
+	if updatedAt > 0 {
+		bucket.UpdatedAt = time.Unix(0, updatedAt)
+	}
+
+	if !bucket.Take(now) {
+		return &job.Job{}, job.ErrorJobNotFound
+	}
+
+	j, err := claim()
+	if err != nil {
+		return j, err
+	}
+
+	u := where()
+	err = exec(ctx, tx, `UPDATE `+queuesTable(tableName)+`
+	SET rate_tokens = `+u.param(bucket.Tokens)+`, rate_updated_at = `+u.param(bucket.UpdatedAt.UnixNano())+`
+	WHERE queue_name = `+u.param(queueName), u.args...)
+
+	return j, err
+}
+
+func queryStats(ctx context.Context, tx *sql.Tx, tableName string) ([]QueueStats, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT queue_name, status, count(*)
+	FROM `+tableName+`
+	GROUP BY queue_name, status
+	ORDER BY queue_name`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	stats := []QueueStats{}
+	for rows.Next() {
+		var queue, status string
+		var count int
+		if err := rows.Scan(&queue, &status, &count); err != nil {
+			return nil, err
+		}
+
+		if len(stats) == 0 || stats[len(stats)-1].Queue != queue {
+			stats = append(stats, QueueStats{Queue: queue, Counts: map[string]int{}})
+		}
+		stats[len(stats)-1].Counts[status] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return queryQueueState(ctx, tx, tableName, stats)
+}
+
+// queryQueueState adds the state of paused or limited queues to stats,
+// including queues without jobs.
+func queryQueueState(ctx context.Context, tx *sql.Tx, tableName string, stats []QueueStats) ([]QueueStats, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT queue_name, paused, max_concurrency, rate_limit, rate_period
+	FROM `+queuesTable(tableName)+`
+	WHERE paused OR max_concurrency > 0 OR rate_limit > 0`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var queue string
+		var paused bool
+		var maxConcurrency, rateLimit int
+		var ratePeriod int64
+		if err := rows.Scan(&queue, &paused, &maxConcurrency, &rateLimit, &ratePeriod); err != nil {
+			return nil, err
+		}
+
+		i := sort.Search(len(stats), func(i int) bool { return stats[i].Queue >= queue })
+		if i == len(stats) || stats[i].Queue != queue {
+			stats = slices.Insert(stats, i, QueueStats{Queue: queue, Counts: map[string]int{}})
+		}
+		stats[i].Paused = paused
+		stats[i].MaxConcurrency = maxConcurrency
+		stats[i].RateLimit = rateLimit
+		stats[i].RatePeriod = time.Duration(ratePeriod)
+	}
+
+	return stats, rows.Err()
+}
+
+// retryWhere reschedules the jobs matching f. Jobs with a parent that has not
+// completed go back to blocked instead, and those whose parent still failed
+// or was canceled afterwards are canceled again by cancelDependents, so a
+// retried dependent never runs before its dependencies.
+func retryWhere(ctx context.Context, tx *sql.Tx, tableName string, f ListFilter, where func() *whereBuilder, now time.Time) (int, error) {
+	w := where()
+	set := `status=CASE WHEN EXISTS (
+			SELECT 1 FROM ` + depsTable(tableName) + ` d
+			JOIN ` + tableName + ` p ON p.id = d.depends_on
+			WHERE d.job_id = ` + tableName + `.id AND p.status <> ` + w.param(job.StatusCompleted) + `
+		) THEN ` + w.param(job.StatusBlocked) + ` ELSE ` + w.param(job.StatusScheduled) + ` END,
+		retry_count=0,
+		last_error=null,
+		started_at=null,
+		scheduled_at=` + w.param(w.timeArg(now)) + `,
+		updated_at=` + w.param(w.timeArg(now))
+
+	w.filter(f)
+	w.add("status NOT IN (?, ?)", job.StatusInitialized, job.StatusBlocked)
+
+	n, err := execAffected(ctx, tx, `UPDATE `+tableName+` SET `+set+w.String(), w.args...)
+	if err != nil || n == 0 {
+		return n, err
+	}
+
+	return n, cancelDependents(ctx, tx, tableName, where, now)
+}
+
+func cancelWhere(ctx context.Context, tx *sql.Tx, tableName string, f ListFilter, where func() *whereBuilder, now time.Time) (int, error) {
+	w := where()
+	set := `status=` + w.param(job.StatusCanceled) + `,
+		updated_at=` + w.param(w.timeArg(now))
+
+	w.filter(f)
+	w.add("status IN (?, ?)", job.StatusScheduled, job.StatusBlocked)
+
+	n, err := execAffected(ctx, tx, `UPDATE `+tableName+` SET `+set+w.String(), w.args...)
+	if err != nil || n == 0 {
+		return n, err
+	}
+
+	return n, cancelDependents(ctx, tx, tableName, where, now)
+}
+
+// purgeBatch selects the ids of the next batch to purge.
+func purgeBatch(tableName string, f ListFilter, limit int, w *whereBuilder) string {
+	w.filter(f)
+	return `SELECT id FROM ` + tableName + w.String() + `
+		ORDER BY updated_at, id
+		LIMIT ` + strconv.Itoa(limit)
+}
+
+func listJobs(ctx context.Context, tx *sql.Tx, tableName string, f ListFilter, where func() *whereBuilder, dest func(*entity) []interface{}) (ListResult, error) {
+	count := where()
+	count.filter(f)
+
+	var total int
+	if err := tx.QueryRowContext(ctx, `SELECT count(*) FROM `+tableName+count.String(), count.args...).Scan(&total); err != nil {
+		return ListResult{}, err
+	}
+
+	page := where()
+	page.filter(f)
+	if f.Cursor != "" {
+		createdAt, id, err := DecodeCursor(f.Cursor)
+		if err != nil {
+			return ListResult{}, err
+		}
+		page.cursor(createdAt, id)
+	}
+
+	limit := f.PageSize()
+	jobs, err := queryJobsWith(ctx, tx, dest, `SELECT `+entryFields+`
+	FROM `+tableName+page.String()+`
+	ORDER BY created_at DESC, id DESC
+	LIMIT `+strconv.Itoa(limit+1), page.args...)
+	if err != nil {
+		return ListResult{}, err
+	}
+
+	return newListResult(jobs, total, limit), nil
+}
+
+func newListResult(jobs []*job.Job, total int, limit int) ListResult {
+	res := ListResult{Jobs: make([]job.Job, 0, len(jobs)), Total: total}
+	for i, j := range jobs {
+		if i == limit {
+			res.Next = EncodeCursor(res.Jobs[limit-1])
+			break
+		}
+		res.Jobs = append(res.Jobs, *j)
+	}
+
+	return res
+}
@@ -0,0 +1,34 @@
+package store
+
+import "time"
+
+// RateLimit is the token bucket of a rate limited queue. It holds up to Limit
+// tokens and refills at Limit tokens per Per; claiming a job takes a token.
+type RateLimit struct {
+	Limit     int
+	Per       time.Duration
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Take refills the bucket up to now and takes a token if one is available.
+// A bucket that was never updated starts full.
+func (r *RateLimit) Take(now time.Time) bool {
+	if r.UpdatedAt.IsZero() {
+		r.Tokens = float64(r.Limit)
+	} else if elapsed := now.Sub(r.UpdatedAt); elapsed > 0 && r.Per > 0 {
+		r.Tokens += float64(r.Limit) * float64(elapsed) / float64(r.Per)
+	}
+
+	r.Tokens = min(r.Tokens, float64(r.Limit))
+	if now.After(r.UpdatedAt) {
+		r.UpdatedAt = now
+	}
+
+	if r.Tokens < 1 {
+		return false
+	}
+
+	r.Tokens--
+	return true
+}
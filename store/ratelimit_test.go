@@ -0,0 +1,24 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitTake(t *testing.T) {
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	r := RateLimit{Limit: 2, Per: time.Minute}
+
+	assert.True(t, r.Take(now), "a new bucket starts full")
+	assert.True(t, r.Take(now))
+	assert.False(t, r.Take(now))
+
+	assert.False(t, r.Take(now.Add(20*time.Second)), "two thirds of a token")
+	assert.True(t, r.Take(now.Add(30*time.Second)))
+
+	assert.True(t, r.Take(now.Add(time.Hour)))
+	assert.True(t, r.Take(now.Add(time.Hour)))
+	assert.False(t, r.Take(now.Add(time.Hour)), "refills up to the limit only")
+}
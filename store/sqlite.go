@@ -0,0 +1,375 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dyaksa/archer/job"
+)
+
+// sqliteTimeFormat is a fixed width UTC layout, so timestamps stored as TEXT
+// compare correctly in SQL.
+const sqliteTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// SQLite is a Backend for single node deployments. Calls are serialized
+// within the process and claims run as a single UPDATE ... RETURNING
+// statement, which SQLite executes under its database write lock instead of
+// relying on SKIP LOCKED. The driver is not imported; open db with the SQLite
+// driver of your choice.
+type SQLite struct {
+	wrappedBackend
+	tableName string
+}
+
+func NewSQLite(db *sql.DB, tableName string) *SQLite {
+	s := &SQLite{tableName: tableName}
+	s.wrappedBackend = wrappedBackend{
+		WrapperTx: *NewWrapperTx(db),
+		tx:        s.bind,
+		mu:        &sync.Mutex{},
+		clock:     systemClock{},
+	}
+
+	return s
+}
+
+// WithTx returns a Backend bound to a transaction owned by the caller.
+func (s *SQLite) WithTx(tx *sql.Tx) Backend {
+	return s.bind(tx)
+}
+
+func (s *SQLite) bind(tx *sql.Tx) fullBackend {
+	return &SQLiteTx{Tx: tx, tableName: s.tableName, now: s.clock.Now}
+}
+
+// Migrate creates the jobs table, its indexes, the queue state table and the
+// job dependency table when they do not exist.
+func (s *SQLite) Migrate(ctx context.Context) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS ` + s.tableName + ` (
+			id TEXT PRIMARY KEY,
+			queue_name TEXT NOT NULL,
+			status TEXT NOT NULL,
+			arguments BLOB NOT NULL DEFAULT '{}',
+			result BLOB,
+			last_error TEXT,
+			retry_count INTEGER NOT NULL DEFAULT 0,
+			max_retry INTEGER NOT NULL DEFAULT 0,
+			retry_interval INTEGER NOT NULL DEFAULT 0,
+			scheduled_at TEXT,
+			started_at TEXT,
+			created_at TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + s.tableName + `_poll_idx ON ` + s.tableName + ` (queue_name, status, scheduled_at)`,
+		`CREATE INDEX IF NOT EXISTS ` + s.tableName + `_started_at_idx ON ` + s.tableName + ` (started_at)`,
+		`CREATE TABLE IF NOT EXISTS ` + queuesTable(s.tableName) + ` (
+			queue_name TEXT PRIMARY KEY,
+			paused INTEGER NOT NULL DEFAULT 0,
+			max_concurrency INTEGER NOT NULL DEFAULT 0,
+			rate_limit INTEGER NOT NULL DEFAULT 0,
+			rate_period INTEGER NOT NULL DEFAULT 0,
+			rate_tokens REAL NOT NULL DEFAULT 0,
+			rate_updated_at INTEGER NOT NULL DEFAULT 0,
+			updated_at TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS ` + depsTable(s.tableName) + ` (
+			job_id TEXT NOT NULL,
+			depends_on TEXT NOT NULL,
+			PRIMARY KEY (job_id, depends_on)
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + depsTable(s.tableName) + `_depends_on_idx ON ` + depsTable(s.tableName) + ` (depends_on)`,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stmt := range statements {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type SQLiteTx struct {
+	*sql.Tx
+	tableName string
+	now       func() time.Time
+}
+
+func NewSQLiteTx(tx *sql.Tx, tableName string) TxStore {
+	return &SQLiteTx{Tx: tx, tableName: tableName, now: time.Now}
+}
+
+func (t *SQLiteTx) Search(ctx context.Context, limit int, offset int, search string) ([]*job.Job, error) {
+	return queryJobsWith(ctx, t.Tx, (*entity).sqliteScanDestinations, `SELECT `+entryFields+`
+	FROM `+t.tableName+`
+	WHERE id LIKE '%' || ? || '%'
+	ORDER BY scheduled_at DESC
+	LIMIT ? OFFSET ?`, search, limit, offset)
+}
+
+func (t *SQLiteTx) where() *whereBuilder {
+	return &whereBuilder{
+		placeholder: func(int) string { return "?" },
+		timeArg:     func(t time.Time) any { return sqliteTime(t) },
+	}
+}
+
+func (t *SQLiteTx) List(ctx context.Context, filter ListFilter) (ListResult, error) {
+	return listJobs(ctx, t.Tx, t.tableName, filter, t.where, (*entity).sqliteScanDestinations)
+}
+
+func (t *SQLiteTx) RetryWhere(ctx context.Context, filter ListFilter) (int, error) {
+	return retryWhere(ctx, t.Tx, t.tableName, filter, t.where, t.now())
+}
+
+func (t *SQLiteTx) CancelWhere(ctx context.Context, filter ListFilter) (int, error) {
+	return cancelWhere(ctx, t.Tx, t.tableName, filter, t.where, t.now())
+}
+
+func (t *SQLiteTx) Get(ctx context.Context, id string) (*job.Job, error) {
+	return queryJobWith(ctx, t.Tx, (*entity).sqliteScanDestinations, `SELECT `+entryFields+`
+	FROM `+t.tableName+`
+	WHERE id = ?`, id)
+}
+
+func (t *SQLiteTx) Update(ctx context.Context, job job.Job) error {
+	now := t.now()
+	err := exec(ctx, t.Tx, `UPDATE `+t.tableName+`
+	SET
+		status=?,
+		result=?,
+		last_error=?,
+		retry_count=?,
+		scheduled_at=?,
+		updated_at=?
+	WHERE id = ?`,
+		job.Status,
+		[]byte(job.Result),
+		job.LastError,
+		job.RetryCount,
+		sqliteTime(job.ScheduleAt),
+		sqliteTime(now),
+		job.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return settleDependents(ctx, t.Tx, t.tableName, job.ID, job.Status, t.where, now, ``)
+}
+
+func (t *SQLiteTx) Create(ctx context.Context, job job.Job) error {
+	status, lastError, err := dependencyStatus(ctx, t.Tx, t.tableName, job, t.where(), ``)
+	if err != nil {
+		return err
+	}
+
+	now := sqliteTime(t.now())
+	err = exec(ctx, t.Tx, `INSERT INTO `+t.tableName+` (id, queue_name, status, arguments, last_error, max_retry, retry_interval, scheduled_at, created_at, updated_at)
+		VALUES (?, ?, ?, COALESCE(?, '{}'), ?, ?, ?, ?, ?, ?)`,
+		job.ID, job.QueueName, status, []byte(job.Arguments), lastError, job.MaxRetry, job.RetryInterval, sqliteTime(job.ScheduleAt), now, now)
+	if err != nil {
+		return err
+	}
+
+	return insertDependencies(ctx, t.Tx, t.tableName, job, t.where)
+}
+
+func (t *SQLiteTx) Deschedule(ctx context.Context, id string) error {
+	now := t.now()
+	err := exec(ctx, t.Tx, `UPDATE `+t.tableName+`
+	SET
+		updated_at=?,
+		status=?
+	WHERE
+		id = ? AND
+		status IN (?, ?)`, sqliteTime(now), job.StatusCanceled, id, job.StatusScheduled, job.StatusBlocked)
+	if err != nil {
+		return err
+	}
+
+	return cancelDependents(ctx, t.Tx, t.tableName, t.where, now)
+}
+
+func (t *SQLiteTx) ScheduleNow(ctx context.Context, id string) error {
+	now := sqliteTime(t.now())
+	return exec(ctx, t.Tx, `UPDATE `+t.tableName+`
+	SET
+		updated_at=?,
+		scheduled_at=?,
+		status=?
+	WHERE
+		id = ? AND
+		status <> ?`, now, now, job.StatusScheduled, id, job.StatusBlocked)
+}
+
+func (t *SQLiteTx) Reschedule(ctx context.Context, id string, at time.Time) error {
+	return exec(ctx, t.Tx, `UPDATE `+t.tableName+`
+	SET
+		updated_at=?,
+		scheduled_at=?,
+		status=CASE WHEN status = ? THEN status ELSE ? END
+	WHERE
+		id = ? AND
+		status <> ?`, sqliteTime(t.now()), sqliteTime(at), job.StatusBlocked, job.StatusScheduled, id, job.StatusInitialized)
+}
+
+// Purge archives and deletes with two statements selecting the same batch;
+// calls are serialized, so nothing changes in between.
+func (t *SQLiteTx) Purge(ctx context.Context, filter ListFilter, limit int, archiveTable string) (int, error) {
+	if archiveTable != "" {
+		w := t.where()
+		batch := purgeBatch(t.tableName, filter, limit, w)
+		if err := exec(ctx, t.Tx, `INSERT INTO `+archiveTable+` (`+entryFields+`)
+		SELECT `+entryFields+` FROM `+t.tableName+` WHERE id IN (`+batch+`)`, w.args...); err != nil {
+			return 0, err
+		}
+	}
+
+	var args []any
+	edges := deleteEdges(t.tableName, func() string {
+		w := t.where()
+		batch := purgeBatch(t.tableName, filter, limit, w)
+		args = append(args, w.args...)
+		return batch
+	})
+	if err := exec(ctx, t.Tx, edges, args...); err != nil {
+		return 0, err
+	}
+
+	w := t.where()
+	batch := purgeBatch(t.tableName, filter, limit, w)
+	return execAffected(ctx, t.Tx, `DELETE FROM `+t.tableName+` WHERE id IN (`+batch+`)`, w.args...)
+}
+
+func (t *SQLiteTx) Delete(ctx context.Context, id string) error {
+	return deleteJob(ctx, t.Tx, t.tableName, id, t.where, t.now(), ``)
+}
+
+func (t *SQLiteTx) Stats(ctx context.Context) ([]QueueStats, error) {
+	return queryStats(ctx, t.Tx, t.tableName)
+}
+
+func (t *SQLiteTx) SetQueuePaused(ctx context.Context, queueName string, paused bool) error {
+	return setQueueState(ctx, t.Tx, t.tableName, queueName, t.where(), t.now(), []string{"paused"}, paused)
+}
+
+func (t *SQLiteTx) SetQueueConcurrency(ctx context.Context, queueName string, n int) error {
+	return setQueueState(ctx, t.Tx, t.tableName, queueName, t.where(), t.now(), []string{"max_concurrency"}, n)
+}
+
+func (t *SQLiteTx) SetQueueRateLimit(ctx context.Context, queueName string, n int, per time.Duration) error {
+	return setQueueState(ctx, t.Tx, t.tableName, queueName, t.where(), t.now(), []string{"rate_limit", "rate_period"}, n, int64(per))
+}
+
+func (t *SQLiteTx) Poll(ctx context.Context, queueName string) (*job.Job, error) {
+	now := t.now()
+	return pollLimited(ctx, t.Tx, t.tableName, queueName, t.where, ``, now, func() (*job.Job, error) {
+		return t.claim(ctx, queueName, now)
+	})
+}
+
+func (t *SQLiteTx) claim(ctx context.Context, queueName string, at time.Time) (*job.Job, error) {
+	now := sqliteTime(at)
+	query := `UPDATE ` + t.tableName + `
+		SET
+			status=?,
+			started_at=?,
+			updated_at=?
+		WHERE
+			id = (
+				SELECT id
+				FROM ` + t.tableName + `
+				WHERE status = ?
+					AND scheduled_at <= ?
+					AND queue_name = ?
+					AND NOT EXISTS (
+						SELECT 1 FROM ` + queuesTable(t.tableName) + `
+						WHERE queue_name = ?
+							AND (paused OR (max_concurrency > 0 AND max_concurrency <= (
+								SELECT count(*) FROM ` + t.tableName + `
+								WHERE queue_name = ? AND status = ?
+							)))
+					)
+				ORDER BY scheduled_at ASC
+				LIMIT 1
+			)
+			AND status = ?
+		RETURNING ` + entryFields
+
+	return queryJobWith(ctx, t.Tx, (*entity).sqliteScanDestinations, query,
+		job.StatusInitialized, now, now, job.StatusScheduled, now, queueName, queueName, queueName, job.StatusInitialized, job.StatusScheduled)
+}
+
+func (t *SQLiteTx) RequeueTimeout(ctx context.Context, queueName string, timeout time.Time) error {
+	return exec(ctx, t.Tx, `UPDATE `+t.tableName+`
+	SET
+		status=?,
+		started_at=null,
+		retry_count=retry_count+1,
+		updated_at=?
+	WHERE
+		started_at < ? AND
+		status = ? AND
+		queue_name = ?`, job.StatusScheduled, sqliteTime(t.now()), sqliteTime(timeout), job.StatusInitialized, queueName)
+}
+
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeFormat)
+}
+
+// liteTime scans timestamps written by sqliteTime. Drivers that already
+// convert the column to time.Time are supported as well.
+type liteTime struct {
+	t     *time.Time
+	valid *bool
+}
+
+func (l liteTime) Scan(src interface{}) error {
+	var t time.Time
+	var err error
+
+	switch v := src.(type) {
+	case nil:
+		*l.t = time.Time{}
+		if l.valid != nil {
+			*l.valid = false
+		}
+		return nil
+	case time.Time:
+		t = v
+	case string:
+		t, err = time.Parse(time.RFC3339Nano, v)
+	case []byte:
+		t, err = time.Parse(time.RFC3339Nano, string(v))
+	default:
+		err = fmt.Errorf("unsupported timestamp type %T", src)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	*l.t = t
+	if l.valid != nil {
+		*l.valid = true
+	}
+
+	return nil
+}
+
+func (e *entity) sqliteScanDestinations() []interface{} {
+	dest := e.ScanDestinations()
+	dest[9] = liteTime{t: &e.ScheduledAt.Time, valid: &e.ScheduledAt.Valid}
+	dest[10] = liteTime{t: &e.StartedAt.Time, valid: &e.StartedAt.Valid}
+	dest[11] = liteTime{t: &e.CreatedAt}
+	dest[12] = liteTime{t: &e.UpdatedAt}
+	return dest
+}
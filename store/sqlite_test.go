@@ -0,0 +1,578 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dyaksa/archer/job"
+	"github.com/stretchr/testify/assert"
+
+	_ "modernc.org/sqlite"
+)
+
+func newSQLite(t *testing.T) *SQLite {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "archer.db")+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	s := NewSQLite(db, "jobs")
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestSQLiteMigrateUpgrade(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "archer.db")+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// the schema before queue state and dependencies were added
+	_, err = db.Exec(`CREATE TABLE jobs (
+		id TEXT PRIMARY KEY,
+		queue_name TEXT NOT NULL,
+		status TEXT NOT NULL,
+		arguments BLOB NOT NULL DEFAULT '{}',
+		result BLOB,
+		last_error TEXT,
+		retry_count INTEGER NOT NULL DEFAULT 0,
+		max_retry INTEGER NOT NULL DEFAULT 0,
+		retry_interval INTEGER NOT NULL DEFAULT 0,
+		scheduled_at TEXT,
+		started_at TEXT,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	)`)
+	assert.NoError(t, err)
+
+	s := NewSQLite(db, "jobs")
+	assert.NoError(t, s.Create(ctx, job.Job{ID: "old", QueueName: "q", Status: job.StatusScheduled, ScheduleAt: time.Now()}))
+
+	_, err = s.Poll(ctx, "q")
+	assert.ErrorContains(t, err, "no such table: jobs_queues")
+
+	assert.NoError(t, s.Migrate(ctx))
+
+	j, err := s.Poll(ctx, "q")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", j.ID)
+	assert.NoError(t, s.SetQueuePaused(ctx, "q", true))
+}
+
+func TestSQLitePoll(t *testing.T) {
+	ctx := context.Background()
+	s := newSQLite(t)
+
+	now := time.Now()
+	assert.NoError(t, s.Create(ctx, job.Job{ID: "later", QueueName: "q", Status: job.StatusScheduled, ScheduleAt: now.Add(time.Hour)}))
+	assert.NoError(t, s.Create(ctx, job.Job{ID: "second", QueueName: "q", Status: job.StatusScheduled, ScheduleAt: now.Add(-time.Second), Arguments: []byte(`{"a":1}`)}))
+	assert.NoError(t, s.Create(ctx, job.Job{ID: "first", QueueName: "q", Status: job.StatusScheduled, ScheduleAt: now.Add(-time.Minute), MaxRetry: 2, RetryInterval: time.Second}))
+	assert.Error(t, s.Create(ctx, job.Job{ID: "first", QueueName: "q", Status: job.StatusScheduled}))
+
+	j, err := s.Poll(ctx, "q")
+	assert.NoError(t, err)
+	assert.Equal(t, "first", j.ID)
+	assert.Equal(t, job.StatusInitialized, j.Status)
+	assert.Equal(t, 2, j.MaxRetry)
+	assert.Equal(t, time.Second, j.RetryInterval)
+	assert.JSONEq(t, `{}`, string(j.Arguments))
+	assert.True(t, j.StartedAt.Valid)
+
+	j, err = s.Poll(ctx, "q")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", j.ID)
+	assert.JSONEq(t, `{"a":1}`, string(j.Arguments))
+
+	_, err = s.Poll(ctx, "q")
+	assert.ErrorIs(t, err, job.ErrorJobNotFound)
+
+	assert.NoError(t, s.ScheduleNow(ctx, "later"))
+	j, err = s.Poll(ctx, "q")
+	assert.NoError(t, err)
+	assert.Equal(t, "later", j.ID)
+}
+
+func TestSQLiteConcurrentPoll(t *testing.T) {
+	ctx := context.Background()
+	s := newSQLite(t)
+
+	for _, id := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		assert.NoError(t, s.Create(ctx, job.Job{ID: id, QueueName: "q", Status: job.StatusScheduled, ScheduleAt: time.Now()}))
+	}
+
+	var mu sync.Mutex
+	claimed := map[string]int{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				j, err := s.Poll(ctx, "q")
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				claimed[j.ID]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, claimed, 8)
+	for id, n := range claimed {
+		assert.Equal(t, 1, n, id)
+	}
+}
+
+func TestSQLiteUpdateRequeueAndCancel(t *testing.T) {
+	ctx := context.Background()
+	s := newSQLite(t)
+
+	assert.NoError(t, s.Create(ctx, job.Job{ID: "j1", QueueName: "q", Status: job.StatusScheduled, ScheduleAt: time.Now()}))
+	assert.NoError(t, s.Create(ctx, job.Job{ID: "j2", QueueName: "q", Status: job.StatusScheduled, ScheduleAt: time.Now().Add(time.Hour)}))
+
+	j, err := s.Poll(ctx, "q")
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.RequeueTimeout(ctx, "q", time.Now().Add(-time.Minute)))
+	j, _ = s.Get(ctx, "j1")
+	assert.Equal(t, job.StatusInitialized, j.Status)
+
+	assert.NoError(t, s.RequeueTimeout(ctx, "q", time.Now().Add(time.Minute)))
+	j, _ = s.Get(ctx, "j1")
+	assert.Equal(t, job.StatusScheduled, j.Status)
+	assert.Equal(t, 1, j.RetryCount)
+	assert.False(t, j.StartedAt.Valid)
+
+	j.Status = job.StatusCompleted
+	j.Result = []byte(`{"ok":true}`)
+	assert.NoError(t, s.Update(ctx, *j))
+	j, _ = s.Get(ctx, "j1")
+	assert.Equal(t, job.StatusCompleted, j.Status)
+	assert.JSONEq(t, `{"ok":true}`, string(j.Result))
+
+	assert.NoError(t, s.Deschedule(ctx, "j2"))
+	j, _ = s.Get(ctx, "j2")
+	assert.Equal(t, job.StatusCanceled, j.Status)
+
+	jobs, err := s.Search(ctx, 10, 0, "j")
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+}
+
+func TestSQLiteList(t *testing.T) {
+	ctx := context.Background()
+	s := newSQLite(t)
+
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	for i, id := range []string{"mail-1", "mail-2", "mail-3", "sms-1", "mail_4"} {
+		s.SetClock(fixedClock(start.Add(time.Duration(i) * time.Minute)))
+		queue := "emails"
+		if id == "sms-1" {
+			queue = "sms"
+		}
+		assert.NoError(t, s.Create(ctx, job.Job{ID: id, QueueName: queue, Status: job.StatusScheduled, ScheduleAt: start}))
+	}
+	assert.NoError(t, s.Deschedule(ctx, "mail-2"))
+
+	res, err := s.List(ctx, ListFilter{Queue: "emails", Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, res.Total)
+	assert.Equal(t, []string{"mail_4", "mail-3"}, ids(res.Jobs))
+	assert.NotEmpty(t, res.Next)
+
+	res, err = s.List(ctx, ListFilter{Queue: "emails", Limit: 2, Cursor: res.Next})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"mail-2", "mail-1"}, ids(res.Jobs))
+	assert.Empty(t, res.Next)
+
+	res, err = s.List(ctx, ListFilter{IDPrefix: "mail-", Statuses: []string{job.StatusScheduled}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"mail-3", "mail-1"}, ids(res.Jobs))
+
+	res, err = s.List(ctx, ListFilter{CreatedAfter: start.Add(time.Minute), CreatedBefore: start.Add(3 * time.Minute)})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"mail-3", "mail-2"}, ids(res.Jobs))
+
+	_, err = s.List(ctx, ListFilter{Cursor: "not a cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func ids(jobs []job.Job) []string {
+	res := []string{}
+	for _, j := range jobs {
+		res = append(res, j.ID)
+	}
+	return res
+}
+
+func TestSQLiteOperations(t *testing.T) {
+	ctx := context.Background()
+	s := newSQLite(t)
+
+	at := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, s.Create(ctx, job.Job{ID: "a", QueueName: "emails", Status: job.StatusScheduled, ScheduleAt: time.Now()}))
+	assert.NoError(t, s.Create(ctx, job.Job{ID: "b", QueueName: "emails", Status: job.StatusScheduled, ScheduleAt: time.Now()}))
+	assert.NoError(t, s.Create(ctx, job.Job{ID: "c", QueueName: "sms", Status: job.StatusScheduled, ScheduleAt: time.Now()}))
+
+	running, err := s.Poll(ctx, "emails")
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Reschedule(ctx, running.ID, at))
+	j, _ := s.Get(ctx, running.ID)
+	assert.Equal(t, job.StatusInitialized, j.Status)
+
+	assert.NoError(t, s.Reschedule(ctx, "c", at))
+	j, _ = s.Get(ctx, "c")
+	assert.Equal(t, at, j.ScheduleAt)
+
+	stats, err := s.Stats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []QueueStats{
+		{Queue: "emails", Counts: map[string]int{job.StatusInitialized: 1, job.StatusScheduled: 1}},
+		{Queue: "sms", Counts: map[string]int{job.StatusScheduled: 1}},
+	}, stats)
+	assert.Equal(t, 2, stats[0].Total())
+
+	assert.NoError(t, s.Delete(ctx, "c"))
+	_, err = s.Get(ctx, "c")
+	assert.ErrorIs(t, err, job.ErrorJobNotFound)
+}
+
+func TestSQLiteBulk(t *testing.T) {
+	ctx := context.Background()
+	s := newSQLite(t)
+
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	s.SetClock(fixedClock(start))
+	for _, id := range []string{"a", "b", "c", "d"} {
+		assert.NoError(t, s.Create(ctx, job.Job{ID: id, QueueName: "emails", Status: job.StatusScheduled, ScheduleAt: start}))
+	}
+
+	for _, id := range []string{"a", "b"} {
+		j, _ := s.Get(ctx, id)
+		j.Status = job.StatusFailed
+		j.RetryCount = 3
+		j.LastError = "smtp down"
+		assert.NoError(t, s.Update(ctx, *j))
+	}
+
+	_, err := s.Poll(ctx, "emails")
+	assert.NoError(t, err)
+
+	later := start.Add(time.Hour)
+	s.SetClock(fixedClock(later))
+
+	n, err := s.RetryWhere(ctx, ListFilter{Queue: "emails", Statuses: []string{job.StatusFailed}, IDPrefix: "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	j, _ := s.Get(ctx, "a")
+	assert.Equal(t, job.StatusScheduled, j.Status)
+	assert.Equal(t, 0, j.RetryCount)
+	assert.Equal(t, "", j.LastError)
+	assert.Equal(t, later, j.ScheduleAt)
+
+	n, err = s.RetryWhere(ctx, ListFilter{Queue: "emails"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n, "every job except the running one")
+
+	n, err = s.CancelWhere(ctx, ListFilter{Queue: "emails", CreatedBefore: later})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	res, err := s.List(ctx, ListFilter{Statuses: []string{job.StatusCanceled}})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, res.Total)
+}
+
+func TestSQLitePurge(t *testing.T) {
+	ctx := context.Background()
+	s := newSQLite(t)
+
+	_, err := s.db.ExecContext(ctx, `CREATE TABLE jobs_archive AS SELECT * FROM jobs WHERE 0`)
+	assert.NoError(t, err)
+
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	for i, id := range []string{"a", "b", "c", "d"} {
+		s.SetClock(fixedClock(start.Add(time.Duration(i) * time.Minute)))
+		assert.NoError(t, s.Create(ctx, job.Job{ID: id, QueueName: "emails", Status: job.StatusCompleted, ScheduleAt: start}))
+	}
+
+	filter := ListFilter{Queue: "emails", Statuses: []string{job.StatusCompleted}, UpdatedBefore: start.Add(3 * time.Minute)}
+
+	n, err := s.Purge(ctx, filter, 2, "jobs_archive")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	var archived int
+	assert.NoError(t, s.db.QueryRowContext(ctx, `SELECT count(*) FROM jobs_archive WHERE id IN ('a', 'b')`).Scan(&archived))
+	assert.Equal(t, 2, archived, "oldest jobs go first")
+
+	n, err = s.Purge(ctx, filter, 2, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	res, err := s.List(ctx, ListFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d"}, ids(res.Jobs))
+}
+
+func TestSQLitePause(t *testing.T) {
+	ctx := context.Background()
+	s := newSQLite(t)
+
+	assert.NoError(t, s.Create(ctx, job.Job{ID: "a", QueueName: "emails", Status: job.StatusScheduled, ScheduleAt: time.Now()}))
+	assert.NoError(t, s.SetQueuePaused(ctx, "emails", true))
+	assert.NoError(t, s.SetQueuePaused(ctx, "reports", true))
+
+	_, err := s.Poll(ctx, "emails")
+	assert.ErrorIs(t, err, job.ErrorJobNotFound)
+
+	stats, err := s.Stats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []QueueStats{
+		{Queue: "emails", Paused: true, Counts: map[string]int{job.StatusScheduled: 1}},
+		{Queue: "reports", Paused: true, Counts: map[string]int{}},
+	}, stats)
+
+	assert.NoError(t, s.SetQueuePaused(ctx, "emails", false))
+
+	j, err := s.Poll(ctx, "emails")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", j.ID)
+}
+
+func TestSQLiteQueueConcurrency(t *testing.T) {
+	ctx := context.Background()
+	s := newSQLite(t)
+
+	for _, id := range []string{"a", "b", "c"} {
+		assert.NoError(t, s.Create(ctx, job.Job{ID: id, QueueName: "emails", Status: job.StatusScheduled, ScheduleAt: time.Now()}))
+	}
+	assert.NoError(t, s.SetQueueConcurrency(ctx, "emails", 2))
+
+	first, err := s.Poll(ctx, "emails")
+	assert.NoError(t, err)
+	_, err = s.Poll(ctx, "emails")
+	assert.NoError(t, err)
+
+	_, err = s.Poll(ctx, "emails")
+	assert.ErrorIs(t, err, job.ErrorJobNotFound, "limit reached")
+
+	stats, err := s.Stats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats[0].MaxConcurrency)
+
+	first.Status = job.StatusCompleted
+	assert.NoError(t, s.Update(ctx, *first))
+
+	_, err = s.Poll(ctx, "emails")
+	assert.NoError(t, err)
+}
+
+func TestSQLiteQueueRateLimit(t *testing.T) {
+	ctx := context.Background()
+	s := newSQLite(t)
+
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	s.SetClock(fixedClock(start))
+	for _, id := range []string{"a", "b", "c"} {
+		assert.NoError(t, s.Create(ctx, job.Job{ID: id, QueueName: "emails", Status: job.StatusScheduled, ScheduleAt: start}))
+	}
+	assert.NoError(t, s.SetQueueRateLimit(ctx, "emails", 2, time.Minute))
+
+	_, err := s.Poll(ctx, "emails")
+	assert.NoError(t, err)
+	_, err = s.Poll(ctx, "emails")
+	assert.NoError(t, err)
+
+	_, err = s.Poll(ctx, "emails")
+	assert.ErrorIs(t, err, job.ErrorJobNotFound, "bucket empty")
+
+	s.SetClock(fixedClock(start.Add(30 * time.Second)))
+	j, err := s.Poll(ctx, "emails")
+	assert.NoError(t, err)
+	assert.Equal(t, "c", j.ID)
+
+	stats, err := s.Stats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats[0].RateLimit)
+	assert.Equal(t, time.Minute, stats[0].RatePeriod)
+}
+
+func TestSQLiteDependencies(t *testing.T) {
+	ctx := context.Background()
+	s := newSQLite(t)
+
+	status := func(id string) string {
+		t.Helper()
+		j, err := s.Get(ctx, id)
+		assert.NoError(t, err)
+		return j.Status
+	}
+
+	finish := func(id string, st string) {
+		t.Helper()
+		j, _ := s.Get(ctx, id)
+		j.Status = st
+		assert.NoError(t, s.Update(ctx, *j))
+	}
+
+	now := time.Now()
+	create := func(id string, parents ...string) error {
+		return s.Create(ctx, job.Job{ID: id, QueueName: "q", Status: job.StatusScheduled, ScheduleAt: now, DependsOn: parents})
+	}
+
+	assert.NoError(t, create("a"))
+	assert.NoError(t, create("b"))
+	assert.NoError(t, create("c", "a", "b"))
+	assert.NoError(t, create("d", "c"))
+	assert.NoError(t, create("e", "d"))
+	assert.ErrorIs(t, create("x", "missing"), job.ErrorJobNotFound)
+
+	assert.Equal(t, job.StatusBlocked, status("c"))
+	assert.NoError(t, s.Reschedule(ctx, "c", now.Add(-time.Minute)))
+	assert.Equal(t, job.StatusBlocked, status("c"), "rescheduling keeps dependencies")
+	assert.NoError(t, s.ScheduleNow(ctx, "c"))
+	assert.Equal(t, job.StatusBlocked, status("c"), "blocked jobs cannot be run now")
+
+	finish("a", job.StatusCompleted)
+	assert.Equal(t, job.StatusBlocked, status("c"))
+
+	finish("b", job.StatusCompleted)
+	assert.Equal(t, job.StatusScheduled, status("c"))
+
+	j, err := s.Poll(ctx, "q")
+	assert.NoError(t, err)
+	assert.Equal(t, "c", j.ID, "blocked jobs are not claimed")
+
+	j.Status = job.StatusFailed
+	assert.NoError(t, s.Update(ctx, *j))
+
+	e, _ := s.Get(ctx, "e")
+	assert.Equal(t, job.StatusCanceled, e.Status)
+	assert.Equal(t, "dependency d canceled", e.LastError)
+
+	assert.NoError(t, create("f", "c"))
+	f, _ := s.Get(ctx, "f")
+	assert.Equal(t, job.StatusCanceled, f.Status)
+	assert.Equal(t, "dependency c failed", f.LastError)
+
+	assert.NoError(t, create("g"))
+	assert.NoError(t, create("h", "g"))
+	n, err := s.CancelWhere(ctx, ListFilter{IDPrefix: "g"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, job.StatusCanceled, status("h"))
+
+	edges := func(where string) int {
+		t.Helper()
+		var n int
+		assert.NoError(t, s.db.QueryRowContext(ctx, `SELECT count(*) FROM jobs_deps`+where).Scan(&n))
+		return n
+	}
+
+	assert.NoError(t, s.Delete(ctx, "c"))
+	assert.Zero(t, edges(` WHERE 'c' IN (job_id, depends_on)`), "deleting a job drops its dependencies")
+	assert.NotZero(t, edges(``))
+
+	_, err = s.Purge(ctx, ListFilter{}, 100, "")
+	assert.NoError(t, err)
+	assert.Zero(t, edges(``), "purging jobs drops their dependencies")
+}
+
+func TestSQLiteDeleteParent(t *testing.T) {
+	ctx := context.Background()
+	s := newSQLite(t)
+
+	status := func(id string) string {
+		t.Helper()
+		j, err := s.Get(ctx, id)
+		assert.NoError(t, err)
+		return j.Status
+	}
+
+	now := time.Now()
+	create := func(id string, parents ...string) {
+		t.Helper()
+		assert.NoError(t, s.Create(ctx, job.Job{ID: id, QueueName: "q", Status: job.StatusScheduled, ScheduleAt: now, DependsOn: parents}))
+	}
+
+	create("p")
+	create("q")
+	create("x", "p")
+	create("y", "p", "q")
+
+	assert.NoError(t, s.Delete(ctx, "p"))
+	assert.Equal(t, job.StatusScheduled, status("x"), "a deleted parent no longer blocks")
+	assert.Equal(t, job.StatusBlocked, status("y"), "other parents still do")
+
+	q, _ := s.Get(ctx, "q")
+	q.Status = job.StatusCompleted
+	assert.NoError(t, s.Update(ctx, *q))
+	assert.Equal(t, job.StatusScheduled, status("y"))
+}
+
+func TestSQLiteRetryDependents(t *testing.T) {
+	ctx := context.Background()
+	s := newSQLite(t)
+
+	status := func(id string) string {
+		t.Helper()
+		j, err := s.Get(ctx, id)
+		assert.NoError(t, err)
+		return j.Status
+	}
+
+	finish := func(id string, st string) {
+		t.Helper()
+		j, _ := s.Get(ctx, id)
+		j.Status = st
+		assert.NoError(t, s.Update(ctx, *j))
+	}
+
+	now := time.Now()
+	create := func(id string, parents ...string) {
+		t.Helper()
+		assert.NoError(t, s.Create(ctx, job.Job{ID: id, QueueName: "q", Status: job.StatusScheduled, ScheduleAt: now, DependsOn: parents}))
+	}
+
+	create("a")
+	create("b", "a")
+	create("c")
+	create("d", "c")
+	finish("a", job.StatusFailed)
+	assert.Equal(t, job.StatusCanceled, status("b"))
+	finish("c", job.StatusCompleted)
+	assert.NoError(t, s.Deschedule(ctx, "d"))
+
+	_, err := s.RetryWhere(ctx, ListFilter{ID: "b", Statuses: []string{job.StatusCanceled}})
+	assert.NoError(t, err)
+	assert.Equal(t, job.StatusCanceled, status("b"), "its parent still failed")
+
+	_, err = s.RetryWhere(ctx, ListFilter{Statuses: []string{job.StatusFailed, job.StatusCanceled}})
+	assert.NoError(t, err)
+	assert.Equal(t, job.StatusScheduled, status("a"))
+	assert.Equal(t, job.StatusBlocked, status("b"), "it waits for its retried parent")
+	assert.Equal(t, job.StatusScheduled, status("d"), "its parent completed")
+
+	finish("a", job.StatusCompleted)
+	assert.Equal(t, job.StatusScheduled, status("b"))
+}
@@ -3,30 +3,25 @@ package store
 import (
 	"context"
 	"database/sql"
+	"strconv"
 	"time"
 
 	"github.com/dyaksa/archer/job"
 )
 
 type TxStore interface {
-	Search(ctx context.Context, limit int, offset int, search string) ([]*job.Job, error)
-	Get(ctx context.Context, id string) (*job.Job, error)
-	Update(ctx context.Context, job job.Job) error
-	Create(ctx context.Context, job job.Job) error
-	Deschedule(ctx context.Context, id string) error
-	ScheduleNow(ctx context.Context, id string) error
-	Poll(ctx context.Context, queueName string) (*job.Job, error)
-	RequeueTimeout(ctx context.Context, queueName string, timeout time.Time) error
+	Backend
 	Commit() error
 }
 
 type Tx struct {
 	*sql.Tx
 	tableName string
+	now       func() time.Time
 }
 
 func NewTx(tx *sql.Tx, tableName string) TxStore {
-	return &Tx{tx, tableName}
+	return &Tx{Tx: tx, tableName: tableName, now: time.Now}
 }
 
 func (t *Tx) Search(ctx context.Context, limit int, offset int, search string) ([]*job.Job, error) {
@@ -44,6 +39,25 @@ func (t *Tx) Search(ctx context.Context, limit int, offset int, search string) (
 	LIMIT $1 OFFSET $2`, limit, offset)
 }
 
+func (t *Tx) where() *whereBuilder {
+	return &whereBuilder{
+		placeholder: func(n int) string { return "$" + strconv.Itoa(n) },
+		timeArg:     func(t time.Time) any { return t },
+	}
+}
+
+func (t *Tx) List(ctx context.Context, filter ListFilter) (ListResult, error) {
+	return listJobs(ctx, t.Tx, t.tableName, filter, t.where, (*entity).ScanDestinations)
+}
+
+func (t *Tx) RetryWhere(ctx context.Context, filter ListFilter) (int, error) {
+	return retryWhere(ctx, t.Tx, t.tableName, filter, t.where, t.now())
+}
+
+func (t *Tx) CancelWhere(ctx context.Context, filter ListFilter) (int, error) {
+	return cancelWhere(ctx, t.Tx, t.tableName, filter, t.where, t.now())
+}
+
 func (t *Tx) Get(ctx context.Context, id string) (*job.Job, error) {
 	return queryJob(ctx, t.Tx, `SELECT `+entryFields+`
 	FROM `+t.tableName+` 
@@ -51,70 +65,169 @@ func (t *Tx) Get(ctx context.Context, id string) (*job.Job, error) {
 }
 
 func (t *Tx) Update(ctx context.Context, job job.Job) error {
-	return exec(ctx, t.Tx, `UPDATE `+t.tableName+`
+	now := t.now()
+	err := exec(ctx, t.Tx, `UPDATE `+t.tableName+`
 	SET
 		status=$1, 
 		result=$2, 
 		last_error=$3, 
 		retry_count=$4,
 		scheduled_at=$5,
-		updated_at=now()
-	WHERE id = $6`,
+		updated_at=$6
+	WHERE id = $7`,
 		job.Status,
 		job.Result,
 		job.LastError,
 		job.RetryCount,
 		job.ScheduleAt,
+		now,
 		job.ID,
 	)
+	if err != nil {
+		return err
+	}
+
+	// Dependents are locked FOR UPDATE before their parents are checked, so
+	// parents completing at the same time cannot all leave them blocked.
+	return settleDependents(ctx, t.Tx, t.tableName, job.ID, job.Status, t.where, now, ` FOR UPDATE`)
 }
 
 func (t *Tx) Create(ctx context.Context, job job.Job) error {
-	return exec(ctx, t.Tx, `INSERT INTO `+t.tableName+` (id, queue_name, status, arguments, max_retry, retry_interval, scheduled_at) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		job.ID, job.QueueName, job.Status, job.Arguments, job.MaxRetry, job.RetryInterval, job.ScheduleAt)
+	// Parents are locked FOR SHARE, so none of them finishes before this job
+	// is committed and can be released.
+	status, lastError, err := dependencyStatus(ctx, t.Tx, t.tableName, job, t.where(), ` FOR SHARE`)
+	if err != nil {
+		return err
+	}
+
+	now := t.now()
+	err = exec(ctx, t.Tx, `INSERT INTO `+t.tableName+` (id, queue_name, status, arguments, last_error, max_retry, retry_interval, scheduled_at, created_at, updated_at) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		job.ID, job.QueueName, status, job.Arguments, lastError, job.MaxRetry, job.RetryInterval, job.ScheduleAt, now, now)
+	if err != nil {
+		return err
+	}
+
+	return insertDependencies(ctx, t.Tx, t.tableName, job, t.where)
 }
 
 func (t *Tx) Deschedule(ctx context.Context, id string) error {
-	return exec(ctx, t.Tx, `UPDATE `+t.tableName+` 
+	now := t.now()
+	err := exec(ctx, t.Tx, `UPDATE `+t.tableName+` 
 	SET 
-		updated_at=now(), 
-		status=$1 
+		updated_at=$1, 
+		status=$2 
 	WHERE 
-		id = $2 AND 
-		status = $3`, job.StatusCanceled, id, job.StatusScheduled)
+		id = $3 AND 
+		status IN ($4, $5)`, now, job.StatusCanceled, id, job.StatusScheduled, job.StatusBlocked)
+	if err != nil {
+		return err
+	}
+
+	return cancelDependents(ctx, t.Tx, t.tableName, t.where, now)
 }
 
 func (t *Tx) ScheduleNow(ctx context.Context, id string) error {
 	return exec(ctx, t.Tx, `UPDATE `+t.tableName+` 
 	SET 
-		updated_at=now(), 
-		scheduled_at=now(), 
-		status=$1 
+		updated_at=$1, 
+		scheduled_at=$1, 
+		status=$2 
 	WHERE 
-		id = $2`, job.StatusScheduled, id)
+		id = $3 AND
+		status <> $4`, t.now(), job.StatusScheduled, id, job.StatusBlocked)
+}
+
+func (t *Tx) Reschedule(ctx context.Context, id string, at time.Time) error {
+	return exec(ctx, t.Tx, `UPDATE `+t.tableName+` 
+	SET 
+		updated_at=$1, 
+		scheduled_at=$2, 
+		status=CASE WHEN status = $3 THEN status ELSE $4 END
+	WHERE 
+		id = $5 AND
+		status <> $6`, t.now(), at, job.StatusBlocked, job.StatusScheduled, id, job.StatusInitialized)
+}
+
+func (t *Tx) Purge(ctx context.Context, filter ListFilter, limit int, archiveTable string) (int, error) {
+	w := t.where()
+	batch := purgeBatch(t.tableName, filter, limit, w)
+
+	purged := `WITH purged AS (
+		DELETE FROM ` + t.tableName + ` WHERE id IN (` + batch + `)
+		RETURNING ` + entryFields + `
+	), edges AS (
+		` + deleteEdges(t.tableName, func() string { return `SELECT id FROM purged` }) + `
+	)`
+
+	if archiveTable == "" {
+		var n int
+		err := t.Tx.QueryRowContext(ctx, purged+` SELECT count(*) FROM purged`, w.args...).Scan(&n)
+		return n, err
+	}
+
+	return execAffected(ctx, t.Tx, purged+`
+	INSERT INTO `+archiveTable+` (`+entryFields+`)
+	SELECT `+entryFields+` FROM purged`, w.args...)
+}
+
+func (t *Tx) Delete(ctx context.Context, id string) error {
+	return deleteJob(ctx, t.Tx, t.tableName, id, t.where, t.now(), ` FOR UPDATE`)
+}
+
+func (t *Tx) Stats(ctx context.Context) ([]QueueStats, error) {
+	return queryStats(ctx, t.Tx, t.tableName)
+}
+
+func (t *Tx) SetQueuePaused(ctx context.Context, queueName string, paused bool) error {
+	return setQueueState(ctx, t.Tx, t.tableName, queueName, t.where(), t.now(), []string{"paused"}, paused)
+}
+
+func (t *Tx) SetQueueConcurrency(ctx context.Context, queueName string, n int) error {
+	return setQueueState(ctx, t.Tx, t.tableName, queueName, t.where(), t.now(), []string{"max_concurrency"}, n)
+}
+
+func (t *Tx) SetQueueRateLimit(ctx context.Context, queueName string, n int, per time.Duration) error {
+	return setQueueState(ctx, t.Tx, t.tableName, queueName, t.where(), t.now(), []string{"rate_limit", "rate_period"}, n, int64(per))
 }
 
 func (t *Tx) Poll(ctx context.Context, queueName string) (*job.Job, error) {
+	// Claims from a limited queue lock its state row, so concurrent pollers
+	// count running jobs and take tokens one after the other.
+	now := t.now()
+	return pollLimited(ctx, t.Tx, t.tableName, queueName, t.where, ` FOR UPDATE`, now, func() (*job.Job, error) {
+		return t.claim(ctx, queueName, now)
+	})
+}
+
+func (t *Tx) claim(ctx context.Context, queueName string, now time.Time) (*job.Job, error) {
 	query := `UPDATE ` + t.tableName + `
 		SET 
 			status=$1, 
-			started_at=now(),
-			updated_at=now()
+			started_at=$2,
+			updated_at=$2
 		WHERE 
 			id = (
 				SELECT id
 				FROM ` + t.tableName + ` 
-				WHERE status = $2
-					AND scheduled_at <= now()
-					AND queue_name = $3
+				WHERE status = $3
+					AND scheduled_at <= $2
+					AND queue_name = $4
+					AND NOT EXISTS (
+						SELECT 1 FROM ` + queuesTable(t.tableName) + `
+						WHERE queue_name = $4
+							AND (paused OR (max_concurrency > 0 AND max_concurrency <= (
+								SELECT count(*) FROM ` + t.tableName + `
+								WHERE queue_name = $4 AND status = $1
+							)))
+					)
 				ORDER BY scheduled_at ASC 
 				FOR UPDATE SKIP LOCKED
 				LIMIT 1 
 			)
 		RETURNING ` + entryFields
 
-	return queryJob(ctx, t.Tx, query, job.StatusInitialized, job.StatusScheduled, queueName)
+	return queryJob(ctx, t.Tx, query, job.StatusInitialized, now, job.StatusScheduled, queueName)
 }
 
 func (t *Tx) RequeueTimeout(ctx context.Context, queueName string, timeout time.Time) error {
@@ -123,9 +236,9 @@ func (t *Tx) RequeueTimeout(ctx context.Context, queueName string, timeout time.
 		status=$1,
 		started_at=null,
 		retry_count=retry_count+1,
-		updated_at=now()
+		updated_at=$2
 	WHERE 
-		started_at < $2 AND 
-		status = $3 AND
-		queue_name = $4`, job.StatusScheduled, timeout, job.StatusInitialized, queueName)
+		started_at < $3 AND 
+		status = $4 AND
+		queue_name = $5`, job.StatusScheduled, t.now(), timeout, job.StatusInitialized, queueName)
 }
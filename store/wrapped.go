@@ -0,0 +1,206 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/dyaksa/archer/job"
+)
+
+// fullBackend is a Backend implementing every optional interface, as the
+// per-transaction backends of this package do.
+type fullBackend interface {
+	Backend
+	Lister
+	Editor
+	BulkEditor
+	Purger
+	StatsReader
+	QueueController
+}
+
+// wrappedBackend turns a per-transaction Backend into one that opens and
+// commits its own transaction for every call. When mu is set, calls are
+// serialized, which databases without row level locking rely on.
+type wrappedBackend struct {
+	WrapperTx
+	tx    func(*sql.Tx) fullBackend
+	mu    *sync.Mutex
+	clock Clock
+}
+
+// SetClock replaces the clock used for timestamps and due checks.
+func (w *wrappedBackend) SetClock(clock Clock) {
+	w.clock = clock
+}
+
+func (w *wrappedBackend) wrap(ctx context.Context, fn func(ctx context.Context, b fullBackend) (any, error)) (any, error) {
+	if w.mu != nil {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+	}
+
+	return w.WrapTx(ctx, func(ctx context.Context, tx *sql.Tx) (any, error) {
+		return fn(ctx, w.tx(tx))
+	})
+}
+
+func (w *wrappedBackend) Create(ctx context.Context, j job.Job) error {
+	_, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return nil, b.Create(ctx, j)
+	})
+	return err
+}
+
+func (w *wrappedBackend) Get(ctx context.Context, id string) (*job.Job, error) {
+	res, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return b.Get(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*job.Job), nil
+}
+
+func (w *wrappedBackend) Poll(ctx context.Context, queueName string) (*job.Job, error) {
+	res, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return b.Poll(ctx, queueName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*job.Job), nil
+}
+
+func (w *wrappedBackend) Update(ctx context.Context, j job.Job) error {
+	_, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return nil, b.Update(ctx, j)
+	})
+	return err
+}
+
+func (w *wrappedBackend) RequeueTimeout(ctx context.Context, queueName string, timeout time.Time) error {
+	_, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return nil, b.RequeueTimeout(ctx, queueName, timeout)
+	})
+	return err
+}
+
+func (w *wrappedBackend) Search(ctx context.Context, limit int, offset int, search string) ([]*job.Job, error) {
+	res, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return b.Search(ctx, limit, offset, search)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.([]*job.Job), nil
+}
+
+func (w *wrappedBackend) List(ctx context.Context, filter ListFilter) (ListResult, error) {
+	res, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return b.List(ctx, filter)
+	})
+	if err != nil {
+		return ListResult{}, err
+	}
+
+	return res.(ListResult), nil
+}
+
+func (w *wrappedBackend) Deschedule(ctx context.Context, id string) error {
+	_, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return nil, b.Deschedule(ctx, id)
+	})
+	return err
+}
+
+func (w *wrappedBackend) ScheduleNow(ctx context.Context, id string) error {
+	_, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return nil, b.ScheduleNow(ctx, id)
+	})
+	return err
+}
+
+func (w *wrappedBackend) Reschedule(ctx context.Context, id string, at time.Time) error {
+	_, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return nil, b.Reschedule(ctx, id, at)
+	})
+	return err
+}
+
+func (w *wrappedBackend) Delete(ctx context.Context, id string) error {
+	_, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return nil, b.Delete(ctx, id)
+	})
+	return err
+}
+
+func (w *wrappedBackend) SetQueuePaused(ctx context.Context, queueName string, paused bool) error {
+	_, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return nil, b.SetQueuePaused(ctx, queueName, paused)
+	})
+	return err
+}
+
+func (w *wrappedBackend) SetQueueConcurrency(ctx context.Context, queueName string, n int) error {
+	_, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return nil, b.SetQueueConcurrency(ctx, queueName, n)
+	})
+	return err
+}
+
+func (w *wrappedBackend) SetQueueRateLimit(ctx context.Context, queueName string, n int, per time.Duration) error {
+	_, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return nil, b.SetQueueRateLimit(ctx, queueName, n, per)
+	})
+	return err
+}
+
+func (w *wrappedBackend) Stats(ctx context.Context) ([]QueueStats, error) {
+	res, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return b.Stats(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.([]QueueStats), nil
+}
+
+func (w *wrappedBackend) RetryWhere(ctx context.Context, filter ListFilter) (int, error) {
+	res, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return b.RetryWhere(ctx, filter)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return res.(int), nil
+}
+
+func (w *wrappedBackend) CancelWhere(ctx context.Context, filter ListFilter) (int, error) {
+	res, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return b.CancelWhere(ctx, filter)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return res.(int), nil
+}
+
+func (w *wrappedBackend) Purge(ctx context.Context, filter ListFilter, limit int, archiveTable string) (int, error) {
+	res, err := w.wrap(ctx, func(ctx context.Context, b fullBackend) (any, error) {
+		return b.Purge(ctx, filter, limit, archiveTable)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return res.(int), nil
+}
@@ -19,18 +19,9 @@ type Tx interface {
 	RequeueTimeout(ctx context.Context, queueName string, timeout time.Time) error
 }
 
-type dbTx interface {
-	Get(ctx context.Context, id string) (*job.Job, error)
-	Poll(ctx context.Context, queueName string) (*job.Job, error)
-	RequeueTimeout(ctx context.Context, queueName string, timeout time.Time) error
-	Create(ctx context.Context, job job.Job) error
-	Update(ctx context.Context, job job.Job) error
-	Deschedule(ctx context.Context, id string) error
-	ScheduleNow(ctx context.Context, id string) error
-}
-
 type transactionClient struct {
-	tx dbTx
+	tx    Backend
+	clock Clock
 }
 
 // Cancel implements Tx.
@@ -50,7 +41,7 @@ func (t *transactionClient) Schedule(ctx context.Context, id string, queueName s
 		ID:         id,
 		QueueName:  queueName,
 		Status:     job.StatusScheduled,
-		ScheduleAt: time.Now(),
+		ScheduleAt: t.clock.Now(),
 	}
 
 	if job, err = job.SetArgs(arguments); err != nil {
@@ -82,6 +73,44 @@ func (t *transactionClient) Update(ctx context.Context, job job.Job) error {
 	return t.tx.Update(ctx, job)
 }
 
+// errTx is the Tx of a client whose backend cannot join a transaction; it
+// fails every call with err.
+type errTx struct {
+	err error
+}
+
+func (t errTx) Schedule(ctx context.Context, id string, queueName string, arguments interface{}, options ...FnOptions) error {
+	return t.err
+}
+
+func (t errTx) Cancel(ctx context.Context, id string) error {
+	return t.err
+}
+
+func (t errTx) ScheduleNow(ctx context.Context, id string) error {
+	return t.err
+}
+
+func (t errTx) Get(ctx context.Context, id string) (*job.Job, error) {
+	return nil, t.err
+}
+
+func (t errTx) Poll(ctx context.Context, queueName string) (*job.Job, error) {
+	return nil, t.err
+}
+
+func (t errTx) Update(ctx context.Context, job job.Job) error {
+	return t.err
+}
+
+func (t errTx) RequeueTimeout(ctx context.Context, queueName string, timeout time.Time) error {
+	return t.err
+}
+
 func newTx(tx *sql.Tx, tableName string) Tx {
-	return &transactionClient{tx: store.NewTx(tx, tableName)}
+	return newBackendTx(store.NewTx(tx, tableName), systemClock{})
+}
+
+func newBackendTx(b Backend, clock Clock) Tx {
+	return &transactionClient{tx: b, clock: clock}
 }
@@ -0,0 +1,333 @@
+// Package ui provides an embeddable web dashboard for inspecting queues and
+// operating on jobs. It has no authentication of its own; mount it behind
+// whatever protects your other internal endpoints.
+package ui
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dyaksa/archer"
+	"github.com/dyaksa/archer/job"
+)
+
+//go:embed templates/*.html
+var templates embed.FS
+
+const recentLimit = 20
+
+type OptionFunc func(*handler) *handler
+
+// WithBasePath sets the path the dashboard is mounted at, e.g. "/archer".
+func WithBasePath(path string) OptionFunc {
+	return func(h *handler) *handler {
+		h.base = strings.TrimSuffix(path, "/")
+		return h
+	}
+}
+
+// WithPageSize sets the number of jobs shown per page, 50 by default.
+func WithPageSize(n int) OptionFunc {
+	return func(h *handler) *handler {
+		h.pageSize = n
+		return h
+	}
+}
+
+type handler struct {
+	client   *archer.Client
+	base     string
+	pageSize int
+	pages    map[string]*template.Template
+	mux      *http.ServeMux
+}
+
+// New returns the dashboard handler for c.
+func New(c *archer.Client, options ...OptionFunc) http.Handler {
+	h := &handler{client: c, pageSize: 50}
+	for _, opt := range options {
+		h = opt(h)
+	}
+
+	funcs := template.FuncMap{
+		"time":       formatTime,
+		"query":      query,
+		"jobTable":   jobTable,
+		"pathEscape": url.PathEscape,
+		"retryable": func(status string) bool {
+			return slices.Contains(retryable, status)
+		},
+	}
+
+	h.pages = map[string]*template.Template{}
+	for _, page := range []string{"overview.html", "jobs.html", "job.html"} {
+		h.pages[page] = template.Must(template.New("").Funcs(funcs).ParseFS(templates, "templates/layout.html", "templates/"+page))
+	}
+
+	h.mux = http.NewServeMux()
+	h.mux.HandleFunc("GET /{$}", h.overview)
+	h.mux.HandleFunc("GET /jobs", h.jobs)
+	h.mux.HandleFunc("GET /jobs/{id}", h.job)
+	h.mux.HandleFunc("POST /jobs/{id}/retry", h.retry)
+	h.mux.HandleFunc("POST /jobs/{id}/cancel", h.cancel)
+	h.mux.HandleFunc("POST /jobs/{id}/reschedule", h.reschedule)
+	h.mux.HandleFunc("POST /jobs/{id}/delete", h.delete)
+	h.mux.HandleFunc("POST /queues/{queue}/pause", h.pause)
+	h.mux.HandleFunc("POST /queues/{queue}/resume", h.resume)
+
+	var mux http.Handler = h.mux
+	if h.base != "" {
+		mux = http.StripPrefix(h.base, h.mux)
+	}
+
+	return sameOrigin(mux)
+}
+
+// sameOrigin rejects state-changing requests a browser sent on behalf of
+// another site, so a page elsewhere cannot make an operator's browser retry,
+// cancel or delete jobs. Requests without Sec-Fetch-Site or Origin headers
+// come from non-browser clients and pass.
+func sameOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch site := r.Header.Get("Sec-Fetch-Site"); site {
+		case "same-origin", "none":
+			next.ServeHTTP(w, r)
+			return
+		case "":
+		default:
+			http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+			return
+		}
+
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || u.Host != r.Host {
+				http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type view struct {
+	Base  string
+	Title string
+	Data  any
+}
+
+func (h *handler) render(w http.ResponseWriter, page string, title string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := h.pages[page].ExecuteTemplate(w, "layout", view{Base: h.base, Title: title, Data: data}); err != nil {
+		slog.Info("error rendering dashboard", "page", page, "err", err)
+	}
+}
+
+func (h *handler) fail(w http.ResponseWriter, err error) {
+	if errors.Is(err, job.ErrorJobNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func (h *handler) overview(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.client.Stats(r.Context())
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+
+	running, err := h.client.List(r.Context(), archer.ListFilter{Statuses: []string{job.StatusInitialized}, Limit: recentLimit})
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+
+	failed, err := h.client.List(r.Context(), archer.ListFilter{Statuses: []string{job.StatusFailed}, Limit: recentLimit})
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+
+	h.render(w, "overview.html", "Queues", map[string]any{
+		"Statuses": statuses,
+		"Stats":    stats,
+		"Running":  running,
+		"Failed":   failed,
+	})
+}
+
+var statuses = []string{
+	job.StatusBlocked,
+	job.StatusScheduled,
+	job.StatusInitialized,
+	job.StatusCompleted,
+	job.StatusFailed,
+	job.StatusCanceled,
+}
+
+func (h *handler) jobs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := archer.ListFilter{
+		Queue:    q.Get("queue"),
+		IDPrefix: q.Get("prefix"),
+		Cursor:   q.Get("cursor"),
+		Limit:    h.pageSize,
+	}
+
+	if status := q.Get("status"); status != "" {
+		filter.Statuses = []string{status}
+	}
+
+	res, err := h.client.List(r.Context(), filter)
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+
+	h.render(w, "jobs.html", "Jobs", map[string]any{
+		"Statuses": statuses,
+		"Filter":   filter,
+		"Status":   q.Get("status"),
+		"Result":   res,
+	})
+}
+
+func (h *handler) job(w http.ResponseWriter, r *http.Request) {
+	res, err := h.client.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+
+	j := res.(*job.Job)
+	h.render(w, "job.html", "Job "+j.ID, j)
+}
+
+// retryable are the statuses a job can be retried from. Running jobs would
+// run twice and blocked jobs wait for their dependencies.
+var retryable = []string{job.StatusFailed, job.StatusCanceled}
+
+func (h *handler) retry(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	res, err := h.client.Get(r.Context(), id)
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+
+	j := res.(*job.Job)
+	if !slices.Contains(retryable, j.Status) {
+		http.Error(w, "job "+j.ID+" is "+j.Status+" and cannot be retried", http.StatusConflict)
+		return
+	}
+
+	// The status filter keeps a job that changed meanwhile untouched.
+	n, err := h.client.RetryWhere(r.Context(), archer.ListFilter{ID: id, Statuses: retryable})
+	if err == nil && n == 0 {
+		http.Error(w, "job "+j.ID+" changed and cannot be retried", http.StatusConflict)
+		return
+	}
+
+	h.done(w, r, err)
+}
+
+func (h *handler) cancel(w http.ResponseWriter, r *http.Request) {
+	_, err := h.client.Cancel(r.Context(), r.PathValue("id"))
+	h.done(w, r, err)
+}
+
+func (h *handler) reschedule(w http.ResponseWriter, r *http.Request) {
+	at, err := parseTime(r.FormValue("at"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.done(w, r, h.client.Reschedule(r.Context(), r.PathValue("id"), at))
+}
+
+func (h *handler) delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.client.Delete(r.Context(), r.PathValue("id")); err != nil {
+		h.fail(w, err)
+		return
+	}
+
+	http.Redirect(w, r, h.base+"/", http.StatusSeeOther)
+}
+
+func (h *handler) pause(w http.ResponseWriter, r *http.Request) {
+	h.queueDone(w, r, h.client.PauseQueue(r.Context(), r.PathValue("queue")))
+}
+
+func (h *handler) resume(w http.ResponseWriter, r *http.Request) {
+	h.queueDone(w, r, h.client.ResumeQueue(r.Context(), r.PathValue("queue")))
+}
+
+// queueDone redirects back to the overview after a queue operation.
+func (h *handler) queueDone(w http.ResponseWriter, r *http.Request, err error) {
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+
+	http.Redirect(w, r, h.base+"/", http.StatusSeeOther)
+}
+
+// done redirects back to the job page after an operation.
+func (h *handler) done(w http.ResponseWriter, r *http.Request, err error) {
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+
+	http.Redirect(w, r, h.base+"/jobs/"+url.PathEscape(r.PathValue("id")), http.StatusSeeOther)
+}
+
+// parseTime accepts RFC 3339 and the value of a datetime-local input, which
+// is read as UTC.
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+
+	return time.ParseInLocation("2006-01-02T15:04", v, time.UTC)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// query renders key/value pairs as a URL query, skipping empty values.
+func query(pairs ...string) template.URL {
+	v := url.Values{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			v.Set(pairs[i], pairs[i+1])
+		}
+	}
+	return template.URL(v.Encode())
+}
+
+// jobTable is the data of the shared "job-table" template.
+func jobTable(base string, jobs []job.Job) map[string]any {
+	return map[string]any{"Base": base, "Jobs": jobs}
+}
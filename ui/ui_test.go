@@ -0,0 +1,214 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dyaksa/archer"
+	"github.com/dyaksa/archer/archertest"
+	"github.com/dyaksa/archer/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDashboard(t *testing.T) (*archer.Client, *httptest.Server) {
+	t.Helper()
+
+	ctx := context.Background()
+	c := archer.NewClientWithBackend(archertest.NewBackend())
+	c.Register("emails", func(ctx context.Context, j job.Job) (any, error) {
+		if j.ID == "broken" {
+			return nil, errors.New("smtp <down>")
+		}
+		return nil, nil
+	})
+
+	for _, id := range []string{"ok", "broken", "waiting"} {
+		_, err := c.Schedule(ctx, id, "emails", map[string]string{"to": id})
+		assert.NoError(t, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := c.ProcessNext(ctx, "emails")
+		assert.NoError(t, err)
+	}
+
+	srv := httptest.NewServer(New(c, WithBasePath("/archer")))
+	t.Cleanup(srv.Close)
+
+	return c, srv
+}
+
+func get(t *testing.T, u string) (int, string) {
+	t.Helper()
+
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b)
+}
+
+func TestOverview(t *testing.T) {
+	_, srv := newDashboard(t)
+
+	code, body := get(t, srv.URL+"/archer/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, `href="/archer/jobs?queue=emails"`)
+	assert.Contains(t, body, `href="/archer/jobs/broken"`)
+	assert.Contains(t, body, "smtp &lt;down&gt;")
+}
+
+func TestJobs(t *testing.T) {
+	_, srv := newDashboard(t)
+
+	code, body := get(t, srv.URL+"/archer/jobs?queue=emails&status=scheduled")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "1 jobs")
+	assert.Contains(t, body, "/archer/jobs/waiting")
+
+	code, body = get(t, srv.URL+"/archer/jobs/waiting")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, `{&#34;to&#34;:&#34;waiting&#34;}`)
+
+	code, _ = get(t, srv.URL+"/archer/jobs/missing")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestActions(t *testing.T) {
+	c, srv := newDashboard(t)
+	ctx := context.Background()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	post := func(path string, form url.Values) *http.Response {
+		resp, err := client.Post(srv.URL+path, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := post("/archer/jobs/broken/retry", nil)
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	assert.Equal(t, "/archer/jobs/broken", resp.Header.Get("Location"))
+	res, _ := c.Get(ctx, "broken")
+	assert.Equal(t, job.StatusScheduled, res.(*job.Job).Status)
+	assert.Zero(t, res.(*job.Job).RetryCount)
+
+	post("/archer/jobs/waiting/reschedule", url.Values{"at": {"2030-01-02T03:04"}})
+	res, _ = c.Get(ctx, "waiting")
+	assert.Equal(t, time.Date(2030, 1, 2, 3, 4, 0, 0, time.UTC), res.(*job.Job).ScheduleAt)
+
+	resp = post("/archer/jobs/waiting/reschedule", url.Values{"at": {"tomorrow"}})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	post("/archer/jobs/waiting/cancel", nil)
+	res, _ = c.Get(ctx, "waiting")
+	assert.Equal(t, job.StatusCanceled, res.(*job.Job).Status)
+
+	resp = post("/archer/jobs/waiting/delete", nil)
+	assert.Equal(t, "/archer/", resp.Header.Get("Location"))
+	_, err := c.Get(ctx, "waiting")
+	assert.ErrorIs(t, err, job.ErrorJobNotFound)
+
+	resp = post("/archer/queues/emails/pause", nil)
+	assert.Equal(t, "/archer/", resp.Header.Get("Location"))
+	stats, _ := c.Stats(ctx)
+	assert.True(t, stats[0].Paused)
+
+	_, body := get(t, srv.URL+"/archer/")
+	assert.Contains(t, body, `action="/archer/queues/emails/resume"`)
+
+	post("/archer/queues/emails/resume", nil)
+	stats, _ = c.Stats(ctx)
+	assert.False(t, stats[0].Paused)
+}
+
+func TestEscapedIDs(t *testing.T) {
+	c, srv := newDashboard(t)
+	ctx := context.Background()
+
+	_, err := c.Schedule(ctx, "w1/split", "emails", nil)
+	assert.NoError(t, err)
+
+	_, body := get(t, srv.URL+"/archer/jobs?queue=emails&status=scheduled")
+	assert.Contains(t, body, `href="/archer/jobs/w1%2Fsplit"`)
+
+	code, body := get(t, srv.URL+"/archer/jobs/w1%2Fsplit")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, `action="/archer/jobs/w1%2Fsplit/cancel"`)
+
+	resp, err := http.Post(srv.URL+"/archer/jobs/w1%2Fsplit/cancel", "application/x-www-form-urlencoded", nil)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	res, _ := c.Get(ctx, "w1/split")
+	assert.Equal(t, job.StatusCanceled, res.(*job.Job).Status)
+}
+
+func TestCrossOriginRejected(t *testing.T) {
+	c, srv := newDashboard(t)
+	ctx := context.Background()
+
+	post := func(header string, value string) int {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/archer/jobs/waiting/cancel", nil)
+		req.Header.Set(header, value)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusForbidden, post("Sec-Fetch-Site", "cross-site"))
+	assert.Equal(t, http.StatusForbidden, post("Origin", "https://evil.example"))
+
+	res, _ := c.Get(ctx, "waiting")
+	assert.Equal(t, job.StatusScheduled, res.(*job.Job).Status)
+
+	assert.Equal(t, http.StatusOK, post("Origin", srv.URL))
+	res, _ = c.Get(ctx, "waiting")
+	assert.Equal(t, job.StatusCanceled, res.(*job.Job).Status)
+}
+
+func TestRetryRunningJob(t *testing.T) {
+	ctx := context.Background()
+	b := archertest.NewBackend()
+	c := archer.NewClientWithBackend(b)
+
+	_, err := c.Schedule(ctx, "running", "emails", nil)
+	assert.NoError(t, err)
+	_, err = b.Poll(ctx, "emails")
+	assert.NoError(t, err)
+	before, _ := b.Get(ctx, "running")
+
+	srv := httptest.NewServer(New(c))
+	t.Cleanup(srv.Close)
+
+	_, body := get(t, srv.URL+"/jobs/running")
+	assert.NotContains(t, body, "Retry now")
+
+	resp, err := http.Post(srv.URL+"/jobs/running/retry", "application/x-www-form-urlencoded", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	after, _ := b.Get(ctx, "running")
+	assert.Equal(t, before, after, "a running job is left alone")
+}
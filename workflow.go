@@ -0,0 +1,229 @@
+package archer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+
+	"github.com/dyaksa/archer/dag"
+	"github.com/dyaksa/archer/job"
+)
+
+// workflowArgs are the arguments of the job running one node of a workflow.
+type workflowArgs struct {
+	Workflow string          `json:"workflow"`
+	Node     string          `json:"node"`
+	Suffix   string          `json:"suffix,omitempty"`
+	Input    json.RawMessage `json:"input"`
+}
+
+// workflowJobID is the id of the job running node in workflow. Nodes below a
+// foreach edge carry the item index in suffix, e.g. "[2]".
+func workflowJobID(workflow string, node string, suffix string) string {
+	return workflow + "/" + node + suffix
+}
+
+// workflowWorker runs one node of a durable workflow per job. Once the node
+// succeeded it schedules a job for every edge to follow, before the handler
+// stores the node output as the job result, so a crash at any point leaves
+// a job behind that picks the workflow up again.
+type workflowWorker struct {
+	dag      *dag.DAG
+	schedule func(ctx context.Context, id string, args workflowArgs, parent job.Job) error
+}
+
+func (w *workflowWorker) Execute(ctx context.Context, j job.Job) (any, error) {
+	var args workflowArgs
+	if err := j.ParseArguments(&args); err != nil {
+		return nil, err
+	}
+
+	var input any
+	if len(args.Input) > 0 {
+		if err := json.Unmarshal(args.Input, &input); err != nil {
+			return nil, fmt.Errorf("node %s input: %w", args.Node, err)
+		}
+	}
+
+	out, edges, err := w.dag.Step(ctx, args.Node, input)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range edges {
+		if !e.Foreach {
+			if err := w.next(ctx, j, args, e.To, args.Suffix, out); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		items, ok := dag.Items(out)
+		if !ok {
+			return nil, fmt.Errorf("node %s: foreach output is %T, not a slice", args.Node, out)
+		}
+
+		for i, item := range items {
+			if err := w.next(ctx, j, args, e.To, args.Suffix+"["+strconv.Itoa(i)+"]", item); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return out, nil
+}
+
+func (w *workflowWorker) next(ctx context.Context, parent job.Job, args workflowArgs, node string, suffix string, input any) error {
+	b, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("node %s output: %w", args.Node, err)
+	}
+
+	next := workflowArgs{Workflow: args.Workflow, Node: node, Suffix: suffix, Input: b}
+	return w.schedule(ctx, workflowJobID(args.Workflow, node, suffix), next, parent)
+}
+
+func (w *workflowWorker) OnFailure(ctx context.Context, j job.Job) error {
+	return nil
+}
+
+// dagWorker runs a whole DAG in memory per job.
+type dagWorker struct {
+	dag *dag.DAG
+}
+
+func (w *dagWorker) Execute(ctx context.Context, j job.Job) (any, error) {
+	// Jobs scheduled without arguments run the DAG with a nil input.
+	var input any
+	if len(j.Arguments) > 0 {
+		if err := j.ParseArguments(&input); err != nil {
+			return nil, err
+		}
+	}
+
+	return w.dag.Execute(ctx, input)
+}
+
+func (w *dagWorker) OnFailure(ctx context.Context, j job.Job) error {
+	return nil
+}
+
+// RegisterDAG registers d as the worker of queue name. Each job runs the
+// whole DAG with its arguments, decoded from JSON, as input and stores the
+// final output as its result. A failing node fails the job, which is retried
+// from the start node like any other job. Use RegisterWorkflow to persist
+// progress between nodes instead.
+func (c *Client) RegisterDAG(name string, d *dag.DAG, options ...WorkerOptionFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.register.registerWorker(name, &dagWorker{dag: d}, options...)
+}
+
+// RegisterWorkflow registers a durable workflow on queue name. Every node of
+// d runs as its own job on that queue, with the node output stored as the
+// job result, so a workflow survives restarts and retries failed nodes on
+// their own. Node inputs and outputs pass through JSON between nodes. A node
+// may have a single parent node, as there is no job that could wait for
+// several. Start workflows with ScheduleWorkflow.
+func (c *Client) RegisterWorkflow(name string, d *dag.DAG, options ...WorkerOptionFunc) {
+	w := &workflowWorker{
+		dag: d,
+		schedule: func(ctx context.Context, id string, args workflowArgs, parent job.Job) error {
+			_, err := c.Schedule(ctx, id, name, args, WithMaxRetries(parent.MaxRetry), WithRetryInterval(parent.RetryInterval))
+			if err == nil {
+				return nil
+			}
+
+			// The node may run again after a crash, or twice at once after a
+			// timeout; its successors exist then, whoever created them.
+			if _, getErr := c.backend.Get(ctx, id); getErr == nil {
+				return nil
+			}
+
+			return err
+		},
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.register.registerWorker(name, w, options...)
+}
+
+// ScheduleWorkflow starts workflow id on the workflow registered as name,
+// with input for its start node. Options apply to the job of the start node;
+// the jobs of later nodes inherit only its retry count and interval. The id
+// must not contain "/", which separates it from node IDs in job IDs. The DAG
+// is validated first, so a broken graph fails here rather than midway.
+func (c *Client) ScheduleWorkflow(ctx context.Context, id string, name string, input any, options ...FnOptions) error {
+	if strings.Contains(id, "/") {
+		return fmt.Errorf("workflow id %s must not contain /", id)
+	}
+
+	config, ok := c.worker(name)
+	if !ok {
+		return fmt.Errorf("no workflow registered for queue %s", name)
+	}
+
+	w, ok := config.w.(*workflowWorker)
+	if !ok {
+		return fmt.Errorf("worker of queue %s is not a workflow", name)
+	}
+
+	if err := w.dag.Validate(); err != nil {
+		return err
+	}
+
+	parents := map[string]string{}
+	for _, n := range w.dag.Nodes() {
+		if n.Join {
+			return fmt.Errorf("join node %s is not supported in workflows", n.ID)
+		}
+
+		for _, e := range n.Edges {
+			if p, ok := parents[e.To]; ok && p != n.ID {
+				return fmt.Errorf("node %s has several parents (%s, %s), which workflows do not support", e.To, p, n.ID)
+			}
+			parents[e.To] = n.ID
+		}
+	}
+
+	b, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	start := w.dag.Start()
+	args := workflowArgs{Workflow: id, Node: start, Input: b}
+	_, err = c.Schedule(ctx, workflowJobID(id, start, ""), name, args, options...)
+	return err
+}
+
+// Workflow returns the node jobs of workflow id created so far, oldest
+// first. Each job's Result holds the output of its node once completed.
+func (c *Client) Workflow(ctx context.Context, id string) ([]job.Job, error) {
+	// Limit is the page size; every page is read below.
+	filter := ListFilter{IDPrefix: id + "/", Limit: 500}
+
+	jobs := []job.Job{}
+	for {
+		res, err := c.List(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, res.Jobs...)
+		if res.Next == "" {
+			break
+		}
+		filter.Cursor = res.Next
+	}
+
+	slices.Reverse(jobs)
+	return jobs, nil
+}